@@ -0,0 +1,53 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestAccessLogIgnorePaths(t *testing.T) {
+	var logs logSink
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithAccessLog(),
+		httpserver.WithAccessLogIgnorePaths("/livez", "/quiet"),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+	})
+
+	for _, path := range []string{"/livez", "/quiet", "/loud"} {
+		s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	lines := logs.records(t, "request")
+	if len(lines) != 1 {
+		t.Fatalf("got %d access log lines, want 1: %v", len(lines), lines)
+	}
+	if lines[0]["path"] != "/loud" {
+		t.Errorf("logged path = %v, want /loud", lines[0]["path"])
+	}
+}
+
+func TestSkipAccessLog(t *testing.T) {
+	var logs logSink
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithAccessLog(),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/quiet" {
+				httpserver.SkipAccessLog(r.Context())
+			}
+		})),
+	})
+
+	for _, path := range []string{"/quiet", "/loud"} {
+		s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	lines := logs.records(t, "request")
+	if len(lines) != 1 || lines[0]["path"] != "/loud" {
+		t.Fatalf("access log lines = %v, want only /loud", lines)
+	}
+}
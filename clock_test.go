@@ -0,0 +1,48 @@
+package httpserver_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestClockDrivesMeasuredDurations(t *testing.T) {
+	var logs logSink
+	clock := newFakeClock()
+	observed := make(chan httpserver.RequestInfo, 1)
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithClock(clock),
+		httpserver.WithRequestObserver(func(info httpserver.RequestInfo) { observed <- info }),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clock.Advance(1500 * time.Millisecond)
+		})),
+		httpserver.WithNamedShutdownHook("flush", func(context.Context) error {
+			clock.Advance(3 * time.Second)
+			return nil
+		}),
+	})
+
+	s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if info := <-observed; info.Duration != 1500*time.Millisecond {
+		t.Errorf("observed duration = %v, want the clock's 1.5s", info.Duration)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec, ok := s.ShutdownRecord()
+	if !ok {
+		t.Fatal("no shutdown record")
+	}
+	if len(rec.Hooks) != 1 || rec.Hooks[0].Duration != 3*time.Second {
+		t.Errorf("recorded hooks = %+v, want flush taking the clock's 3s", rec.Hooks)
+	}
+	if rec.Duration != 3*time.Second {
+		t.Errorf("shutdown duration = %v, want the clock's 3s", rec.Duration)
+	}
+}
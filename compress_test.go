@@ -0,0 +1,113 @@
+package httpserver_test
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestCompression(t *testing.T) {
+	var logs logSink
+	long := strings.Repeat("compressible text ", 200)
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithCompression(flate.DefaultCompression),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/short":
+				io.WriteString(w, "short")
+			case "/encoded":
+				w.Header().Set("Content-Type", "text/plain")
+				w.Header().Set("Content-Encoding", "br")
+				io.WriteString(w, long)
+			default:
+				io.WriteString(w, long)
+			}
+		})),
+	})
+	serve := func(path, acceptEncoding string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		resp := rec.Result()
+		if vary := resp.Header.Values("Vary"); len(vary) != 1 || vary[0] != "Accept-Encoding" {
+			t.Errorf("%s: Vary = %q, want Accept-Encoding", path, vary)
+		}
+		return resp
+	}
+	body := func(r io.Reader) string {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		resp := serve("/", "deflate;q=0.5, gzip")
+		if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", got)
+		}
+		if resp.Header.Get("Content-Length") != "" {
+			t.Error("compressed response kept its Content-Length")
+		}
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := body(zr); got != long {
+			t.Errorf("decompressed body differs from the handler's (%d bytes, want %d)", len(got), len(long))
+		}
+	})
+
+	t.Run("deflate", func(t *testing.T) {
+		resp := serve("/", "gzip;q=0.1, deflate")
+		if got := resp.Header.Get("Content-Encoding"); got != "deflate" {
+			t.Fatalf("Content-Encoding = %q, want deflate", got)
+		}
+		if got := body(flate.NewReader(resp.Body)); got != long {
+			t.Errorf("decompressed body differs from the handler's (%d bytes, want %d)", len(got), len(long))
+		}
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		for _, ae := range []string{"", "identity", "gzip;q=0"} {
+			resp := serve("/", ae)
+			if got := resp.Header.Get("Content-Encoding"); got != "" {
+				t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want none", ae, got)
+			}
+			if got := body(resp.Body); got != long {
+				t.Errorf("Accept-Encoding %q: body altered", ae)
+			}
+		}
+	})
+
+	t.Run("below min size", func(t *testing.T) {
+		resp := serve("/short", "gzip")
+		if got := resp.Header.Get("Content-Encoding"); got != "" {
+			t.Errorf("Content-Encoding = %q, want none", got)
+		}
+		if got := body(resp.Body); got != "short" {
+			t.Errorf("body = %q, want %q", got, "short")
+		}
+	})
+
+	t.Run("already encoded", func(t *testing.T) {
+		resp := serve("/encoded", "gzip")
+		if got := resp.Header.Values("Content-Encoding"); len(got) != 1 || got[0] != "br" {
+			t.Errorf("Content-Encoding = %q, want the handler's br alone", got)
+		}
+		if got := body(resp.Body); got != long {
+			t.Error("already encoded body was compressed again")
+		}
+	})
+}
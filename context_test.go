@@ -0,0 +1,54 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/banan-tech/httpserver"
+)
+
+type tenantKey struct{}
+
+func TestBaseContextReachesHandlersAndShutdownCancels(t *testing.T) {
+	var logs logSink
+	entered := make(chan string, 1)
+	cancelled := make(chan error, 1)
+	s, addr := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithBaseContext(func(net.Listener) context.Context {
+			return context.WithValue(context.Background(), tenantKey{}, "acme")
+		}),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant, _ := r.Context().Value(tenantKey{}).(string)
+			entered <- tenant
+			<-r.Context().Done()
+			cancelled <- r.Context().Err()
+		})),
+	)
+
+	go func() {
+		if resp, err := http.Get("http://" + addr + "/"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	if tenant := <-entered; tenant != "acme" {
+		t.Fatalf("handler saw tenant %q, want acme", tenant)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = s.Shutdown(ctx)
+
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("request context error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown did not cancel the request context")
+	}
+}
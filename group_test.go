@@ -0,0 +1,89 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestGroupRunWaitsForShutdownElsewhere(t *testing.T) {
+	var logs logSink
+	var hooked atomic.Bool
+	failure := errors.New("flush failed")
+	public := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithListener(listenLoopback(t)),
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithShutdownHook(func(context.Context) error {
+			time.Sleep(300 * time.Millisecond)
+			hooked.Store(true)
+			return failure
+		}),
+	})
+	admin := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithListener(listenLoopback(t)),
+		httpserver.WithLogger(logs.logger()),
+	})
+	group := httpserver.NewGroup(public, admin)
+	errc := make(chan error, 1)
+	go func() { errc <- group.RunContext(context.Background()) }()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, s := range []*httpserver.Server{public, admin} {
+		if err := s.WaitReady(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- group.Shutdown(context.Background()) }()
+	select {
+	case err := <-errc:
+		if !hooked.Load() {
+			t.Error("RunContext returned before the member's shutdown hook finished")
+		}
+		if !errors.Is(err, failure) {
+			t.Errorf("RunContext = %v, want the member's shutdown error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return after shutdown")
+	}
+	if err := <-shutdown; !errors.Is(err, failure) {
+		t.Errorf("Shutdown = %v, want the member's shutdown error", err)
+	}
+}
+
+func TestGroupSkipsHooksOfMemberThatNeverStarted(t *testing.T) {
+	var logs logSink
+	taken := listenLoopback(t)
+	defer taken.Close()
+	var hooked atomic.Bool
+	broken := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithAddr(taken.Addr().String()),
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithShutdownHook(func(context.Context) error {
+			hooked.Store(true)
+			return nil
+		}),
+	})
+	healthy := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithListener(listenLoopback(t)),
+		httpserver.WithLogger(logs.logger()),
+	})
+	errc := make(chan error, 1)
+	go func() { errc <- httpserver.NewGroup(broken, healthy).RunContext(context.Background()) }()
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Error("RunContext = nil, want the bind error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return after a member failed to start")
+	}
+	if hooked.Load() {
+		t.Error("shutdown hook ran for a member that never started")
+	}
+}
@@ -0,0 +1,35 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestMaxHeaderBytes(t *testing.T) {
+	_, addr := startServer(t,
+		httpserver.WithMaxHeaderBytes(1024),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+	)
+	req := "GET / HTTP/1.1\r\nHost: x\r\nConnection: close\r\n"
+	if resp := rawRequest(t, addr, req+"\r\n"); resp.StatusCode != http.StatusOK {
+		t.Errorf("small headers got %d, want 200", resp.StatusCode)
+	}
+	// net/http allows 4096 bytes of slack over the limit.
+	big := req + "X-Big: " + strings.Repeat("a", 16<<10) + "\r\n\r\n"
+	if resp := rawRequest(t, addr, big); resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("oversized headers got %d, want 431", resp.StatusCode)
+	}
+}
+
+func TestNegativeMaxHeaderBytesPanicsInNew(t *testing.T) {
+	opt := httpserver.WithMaxHeaderBytes(-1)
+	defer func() {
+		if recover() == nil {
+			t.Error("New did not panic on negative max header bytes")
+		}
+	}()
+	httpserver.New([]httpserver.ServerOption{opt})
+}
@@ -8,7 +8,9 @@ import (
 	lisette "github.com/ivov/lisette/prelude"
 	"log/slog"
 	"net/http"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const CONTENT_TYPE string = "Content-Type"
@@ -17,6 +19,8 @@ const CONTENT_TYPE_PLAIN string = "text/plain; charset=utf-8"
 
 const CONTENT_TYPE_JSON string = "application/json"
 
+const READINESS_CHECK_TIMEOUT = 2 * time.Second
+
 type CheckFunc func(context.Context) error
 
 type NamedCheck struct {
@@ -24,6 +28,23 @@ type NamedCheck struct {
 	check CheckFunc
 }
 
+type ReadinessChecks struct {
+	mu     sync.RWMutex
+	checks []NamedCheck
+}
+
+func (r *ReadinessChecks) add(check NamedCheck) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check)
+}
+
+func (r *ReadinessChecks) list() []NamedCheck {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.checks
+}
+
 func liveness_handler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -34,7 +55,24 @@ func liveness_handler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]uint8("ok"))
 }
 
-func readiness_handler(ready *atomic.Bool, checks []NamedCheck, logger *slog.Logger) http.Handler {
+func HealthHandler() http.Handler {
+	return http.HandlerFunc(liveness_handler)
+}
+
+func health_middleware(path string) func(http.Handler) http.Handler {
+	health := HealthHandler()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == path {
+				health.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func readiness_handler(ready *atomic.Bool, checks *ReadinessChecks, logger *slog.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -46,9 +84,16 @@ func readiness_handler(ready *atomic.Bool, checks []NamedCheck, logger *slog.Log
 			w.Write([]uint8("shutting down"))
 			return
 		}
-		for _, c := range checks {
+		ctx, cancel := context.WithTimeout(r.Context(), READINESS_CHECK_TIMEOUT)
+		defer cancel()
+		statuses := ([]map[string]string)(nil)
+		failed := false
+		for _, c := range checks.list() {
+			status := make(map[string]string)
+			status["name"] = c.name
+			status["status"] = "ok"
 			callee_2 := c.check
-			ret_3 := callee_2(r.Context())
+			ret_3 := callee_2(ctx)
 			var result_4 lisette.Result[struct{}, error]
 			if ret_3 != nil {
 				result_4 = lisette.MakeResultErr[struct{}, error](ret_3)
@@ -59,14 +104,19 @@ func readiness_handler(ready *atomic.Bool, checks []NamedCheck, logger *slog.Log
 			if subject_1.Tag == lisette.ResultErr {
 				e := subject_1.ErrVal
 				logger.WarnContext(r.Context(), "readiness check failed", "check", c.name, "error", e.Error())
-				w.Header().Set(CONTENT_TYPE, CONTENT_TYPE_JSON)
-				w.WriteHeader(http.StatusServiceUnavailable)
-				body := make(map[string]string)
-				body["failed_check"] = c.name
-				body["error"] = e.Error()
-				json.NewEncoder(w).Encode(body)
-				return
+				status["status"] = "failed"
+				status["error"] = e.Error()
+				failed = true
 			}
+			statuses = append(statuses, status)
+		}
+		if failed {
+			w.Header().Set(CONTENT_TYPE, CONTENT_TYPE_JSON)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			body := make(map[string][]map[string]string)
+			body["checks"] = statuses
+			json.NewEncoder(w).Encode(body)
+			return
 		}
 		w.Header().Set(CONTENT_TYPE, CONTENT_TYPE_PLAIN)
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,35 @@
+package httpserver_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestHealthEndpoint(t *testing.T) {
+	_, addr := startServer(t,
+		httpserver.WithHealthEndpoint("/healthz"),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, "app")
+		})),
+	)
+	if status, body := fetch(t, "http://"+addr+"/healthz"); status != http.StatusOK || body != "ok" {
+		t.Errorf("GET /healthz = %d %q, want 200 %q", status, body, "ok")
+	}
+	if status, _ := fetch(t, "http://"+addr+"/healthz/x"); status != http.StatusTeapot {
+		t.Errorf("GET /healthz/x = %d, want the handler's %d", status, http.StatusTeapot)
+	}
+}
+
+func TestEmptyHealthEndpointPanicsInNew(t *testing.T) {
+	opt := httpserver.WithHealthEndpoint("")
+	defer func() {
+		if recover() == nil {
+			t.Error("New did not panic on an empty health endpoint path")
+		}
+	}()
+	httpserver.New([]httpserver.ServerOption{opt})
+}
@@ -0,0 +1,86 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestConcurrentShutdownHooks(t *testing.T) {
+	var logs logSink
+	var running, peak, ran atomic.Int32
+	hook := func(context.Context) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		running.Add(-1)
+		ran.Add(1)
+		return nil
+	}
+	s, _ := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithConcurrentShutdownHooks(2),
+		httpserver.WithNamedShutdownHook("a", hook),
+		httpserver.WithNamedShutdownHook("b", hook),
+		httpserver.WithNamedShutdownHook("c", hook),
+	)
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := ran.Load(); n != 3 {
+		t.Errorf("%d hooks ran, want 3", n)
+	}
+	if p := peak.Load(); p != 2 {
+		t.Errorf("at most %d hooks ran at once, want 2", p)
+	}
+	rec, ok := s.ShutdownRecord()
+	if !ok {
+		t.Fatal("no shutdown record")
+	}
+	var names []string
+	for _, h := range rec.Hooks {
+		names = append(names, h.Name)
+	}
+	if len(names) != 3 || names[0] != "a" || names[1] != "b" || names[2] != "c" {
+		t.Errorf("recorded hooks %v, want [a b c] in registration order", names)
+	}
+}
+
+func TestShutdownHookTimeout(t *testing.T) {
+	var logs logSink
+	stuck := make(chan struct{})
+	defer close(stuck)
+	var next atomic.Bool
+	s, _ := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithShutdownHookTimeout(50*time.Millisecond, func(context.Context) error {
+			<-stuck // ignores its context
+			return nil
+		}),
+		httpserver.WithShutdownHook(func(context.Context) error {
+			next.Store(true)
+			return nil
+		}),
+	)
+
+	began := time.Now()
+	err := s.Shutdown(context.Background())
+	if took := time.Since(began); took > 2*time.Second {
+		t.Errorf("Shutdown took %v, waiting for the stuck hook", took)
+	}
+	if !errors.Is(err, httpserver.ErrHookTimeout) {
+		t.Errorf("Shutdown = %v, want ErrHookTimeout", err)
+	}
+	if !next.Load() {
+		t.Error("the hook after the stuck one did not run")
+	}
+}
@@ -0,0 +1,42 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestAllowedMethods(t *testing.T) {
+	var logs logSink
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithAllowedMethods("/api", "get"),
+		httpserver.WithAllowedMethods("/api/admin", http.MethodPost, http.MethodDelete),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+	})
+
+	tests := []struct {
+		method, path string
+		want         int
+		allow        string
+	}{
+		{http.MethodGet, "/api/things", http.StatusOK, ""},
+		{http.MethodHead, "/api/things", http.StatusOK, ""},
+		{http.MethodPost, "/api/things", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{http.MethodDelete, "/api/admin/users", http.StatusOK, ""},
+		{http.MethodGet, "/api/admin/users", http.StatusMethodNotAllowed, "POST, DELETE"},
+		{http.MethodPut, "/public", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+		if got := rec.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s: Allow = %q, want %q", tt.method, tt.path, got, tt.allow)
+		}
+	}
+}
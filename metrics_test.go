@@ -0,0 +1,145 @@
+package httpserver_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.PathValue("id"))
+	})
+	mux.HandleFunc("PUT /slow", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+	mux.HandleFunc("/any", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	reg := prometheus.NewRegistry()
+	_, addr := startServer(t,
+		httpserver.WithHandler(mux),
+		httpserver.WithMetricsRegistry(reg, "/metrics"),
+	)
+
+	for _, id := range []string{"1", "2"} {
+		if status, _ := fetch(t, "http://"+addr+"/items/"+id); status != http.StatusOK {
+			t.Fatalf("GET /items/%s = %d", id, status)
+		}
+	}
+	fetch(t, "http://"+addr+"/missing")
+	purge, err := http.NewRequest("PURGE", "http://"+addr+"/any", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(purge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req, _ := http.NewRequest(http.MethodPut, "http://"+addr+"/slow", nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-entered
+	scraped := scrape(t, addr)
+	close(release)
+	<-done
+
+	for _, want := range []string{
+		`http_requests_total{code="200",method="GET",route="GET /items/{id}"} 2`,
+		`http_requests_total{code="404",method="GET",route="unmatched"} 1`,
+		`http_requests_total{code="202",method="OTHER",route="/any"} 1`,
+		`http_request_duration_seconds_count{code="200",method="GET",route="GET /items/{id}"} 2`,
+		`http_requests_in_flight{method="PUT"} 1`,
+	} {
+		if !strings.Contains(scraped, want) {
+			t.Errorf("scrape lacks %s", want)
+		}
+	}
+	if strings.Contains(scraped, `method="PURGE"`) {
+		t.Error("non-standard method kept as a label, want OTHER")
+	}
+	if want := `http_requests_in_flight{method="PUT"} 0`; !strings.Contains(scrape(t, addr), want) {
+		t.Errorf("scrape after the request lacks %s", want)
+	}
+}
+
+func TestMetricsRegistrySharedByServers(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	var addrs []string
+	for i := 0; i < 2; i++ {
+		_, addr := startServer(t,
+			httpserver.WithHandler(http.NotFoundHandler()),
+			httpserver.WithMetricsRegistry(reg, "/metrics"),
+		)
+		addrs = append(addrs, addr)
+	}
+	for _, addr := range addrs {
+		fetch(t, "http://"+addr+"/x")
+	}
+	want := `http_requests_total{code="404",method="GET",route="/"} 2`
+	for _, addr := range addrs {
+		if !strings.Contains(scrape(t, addr), want) {
+			t.Errorf("scrape from %s lacks %s", addr, want)
+		}
+	}
+}
+
+func TestMetricsRegistryConflictsAreLogged(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		existing prometheus.Collector
+	}{
+		{"another type", prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by method, route and status code.",
+		}, []string{"method", "route", "code"})},
+		{"other labels", prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_total",
+			Help: "Something else.",
+		})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs logSink
+			reg := prometheus.NewRegistry()
+			reg.MustRegister(tc.existing)
+			_, addr := startServer(t,
+				httpserver.WithLogger(logs.logger()),
+				httpserver.WithHandler(http.NotFoundHandler()),
+				httpserver.WithMetricsRegistry(reg, "/metrics"),
+			)
+			if len(logs.records(t, "metrics registration failed")) == 0 {
+				t.Error("conflict not logged")
+			}
+			fetch(t, "http://"+addr+"/x")
+			want := `http_request_duration_seconds_count{code="404",method="GET",route="/"} 1`
+			if !strings.Contains(scrape(t, addr), want) {
+				t.Errorf("scrape lacks %s", want)
+			}
+		})
+	}
+}
+
+// scrape returns the metrics served at addr's /metrics.
+func scrape(t *testing.T, addr string) string {
+	t.Helper()
+	status, body := fetch(t, "http://"+addr+"/metrics")
+	if status != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", status)
+	}
+	return body
+}
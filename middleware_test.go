@@ -0,0 +1,39 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	var logs logSink
+	var calls []string
+	trace := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+" in")
+				next.ServeHTTP(w, r)
+				calls = append(calls, name+" out")
+			})
+		}
+	}
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithMiddleware(trace("outer"), trace("inner")),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "handler")
+		})),
+	})
+	s.Use(trace("used"))
+
+	s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := []string{"outer in", "inner in", "used in", "handler", "used out", "inner out", "outer out"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
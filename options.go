@@ -3,31 +3,119 @@
 package httpserver
 
 import (
+	"compress/flate"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	lisette "github.com/ivov/lisette/prelude"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/acme/autocert"
+	"io"
+	"log"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
+	"slices"
+	"syscall"
 	"time"
 )
 
 type Config struct {
-	addr                   string
-	handler                lisette.Option[http.Handler]
-	metrics_handler        lisette.Option[http.Handler]
-	logger                 *slog.Logger
-	read_header_timeout    time.Duration
-	read_timeout           time.Duration
-	write_timeout          time.Duration
-	idle_timeout           time.Duration
-	shutdown_timeout       time.Duration
-	readiness_checks       []NamedCheck
-	shutdown_hooks         []ShutdownHook
-	disable_default_probes bool
-	liveness_path          string
-	readiness_path         string
+	addr                    string
+	handler                 lisette.Option[http.Handler]
+	metrics_handler         lisette.Option[http.Handler]
+	metrics                 lisette.Option[MetricsSetup]
+	middleware              []func(http.Handler) http.Handler
+	handler_middleware      []func(http.Handler) http.Handler
+	log_context             []LogContextFunc
+	logger                  *slog.Logger
+	read_header_timeout     time.Duration
+	read_timeout            time.Duration
+	write_timeout           time.Duration
+	idle_timeout            time.Duration
+	max_header_bytes        int
+	shutdown_timeout        time.Duration
+	readiness_checks        []NamedCheck
+	shutdown_hooks          []NamedHook
+	disable_default_probes  bool
+	liveness_path           string
+	readiness_path          string
+	health_path             lisette.Option[string]
+	access_log              bool
+	access_log_ignore_paths []string
+	access_log_skips        []func(*http.Request) bool
+	clock                   Clock
+	require_https           lisette.Option[HTTPSBehavior]
+	trusted_proxies         []netip.Prefix
+	unknown_route_label     string
+	warmups                 []WarmupFunc
+	warmup_required         bool
+	accept_error_handler    lisette.Option[AcceptErrorHandler]
+	base_context            lisette.Option[BaseContextFunc]
+	listener_name           string
+	shutdown_response       lisette.Option[ShutdownResponse]
+	hook_slow_threshold     float64
+	hook_concurrency        int
+	pre_shutdown_delay      time.Duration
+	certificates            []tls.Certificate
+	shutdown_progress       lisette.Option[chan ShutdownPhase]
+	alpn_handlers           map[string]ALPNHandler
+	auto_head               bool
+	request_observer        lisette.Option[RequestObserver]
+	hook_budget             time.Duration
+	embedded                bool
+	strict_requests         bool
+	post_bind_hooks         []PostBindHook
+	startup_hooks           []StartupHook
+	track_in_flight         bool
+	flag_provider           lisette.Option[FlagProvider]
+	error_logger            lisette.Option[*log.Logger]
+	accept_types            []string
+	deadline_header         lisette.Option[string]
+	recovery                bool
+	options_handler         lisette.Option[OptionsFunc]
+	idle_jitter             float64
+	total_request_timeout   time.Duration
+	request_timeout         time.Duration
+	request_timeout_exempt  []string
+	cancel_threshold        time.Duration
+	request_id              lisette.Option[RequestIDGenerator]
+	request_id_headers      []string
+	key_pairs               []KeyPairFiles
+	streaming               bool
+	compression_level       lisette.Option[int]
+	compression_min_size    int
+	compression_skip_types  []string
+	allowed_methods         map[string][]string
+	custom_tls              lisette.Option[*tls.Config]
+	redirect_addr           lisette.Option[string]
+	autocert                lisette.Option[*autocert.Manager]
+	client_cas              lisette.Option[*x509.CertPool]
+	client_auth             tls.ClientAuthType
+	config_file             lisette.Option[ConfigFile]
+	access_log_format       AccessLogFormat
+	access_log_writer       io.Writer
+	unix_socket             lisette.Option[string]
+	listener                lisette.Option[net.Listener]
+	starting_response       StartingResponse
+	forwarded_proto_header  string
+	client_ip_header        string
+	rate_limit              lisette.Option[RateLimit]
+	rate_limit_key          lisette.Option[RateLimitKeyFunc]
+	panic_observer          lisette.Option[PanicObserver]
+	panic_threshold         int
+	panic_window            time.Duration
+	shutdown_signals        []os.Signal
+	listen_config           net.ListenConfig
+	reuse_port              bool
+	conn_state_hooks        []ConnStateFunc
+	conn_metrics            bool
 }
 
+var DefaultShutdownTimeout time.Duration = 15 * time.Second
+
 func default_config() Config {
 	return Config{
 		addr:                   ":8080",
@@ -36,12 +124,51 @@ func default_config() Config {
 		read_timeout:           15 * time.Second,
 		write_timeout:          70 * time.Second,
 		idle_timeout:           90 * time.Second,
-		shutdown_timeout:       15 * time.Second,
+		shutdown_timeout:       DefaultShutdownTimeout,
 		liveness_path:          "/livez",
 		readiness_path:         "/readyz",
 		handler:                lisette.MakeOptionNone[http.Handler](),
 		metrics_handler:        lisette.MakeOptionNone[http.Handler](),
+		metrics:                lisette.MakeOptionNone[MetricsSetup](),
+		require_https:          lisette.MakeOptionNone[HTTPSBehavior](),
+		accept_error_handler:   lisette.MakeOptionNone[AcceptErrorHandler](),
+		base_context:           lisette.MakeOptionNone[BaseContextFunc](),
+		shutdown_response:      lisette.MakeOptionNone[ShutdownResponse](),
+		clock:                  RealClock{},
+		unknown_route_label:    "unmatched",
+		listener_name:          "default",
+		hook_slow_threshold:    0.5,
 		disable_default_probes: false,
+		shutdown_progress:      lisette.MakeOptionNone[chan ShutdownPhase](),
+		health_path:            lisette.MakeOptionNone[string](),
+		alpn_handlers:          make(map[string]ALPNHandler),
+		request_observer:       lisette.MakeOptionNone[RequestObserver](),
+		hook_budget:            3 * time.Second,
+		flag_provider:          lisette.MakeOptionNone[FlagProvider](),
+		error_logger:           lisette.MakeOptionNone[*log.Logger](),
+		deadline_header:        lisette.MakeOptionNone[string](),
+		options_handler:        lisette.MakeOptionNone[OptionsFunc](),
+		request_id:             lisette.MakeOptionNone[RequestIDGenerator](),
+		allowed_methods:        make(map[string][]string),
+		custom_tls:             lisette.MakeOptionNone[*tls.Config](),
+		redirect_addr:          lisette.MakeOptionNone[string](),
+		autocert:               lisette.MakeOptionNone[*autocert.Manager](),
+		client_cas:             lisette.MakeOptionNone[*x509.CertPool](),
+		config_file:            lisette.MakeOptionNone[ConfigFile](),
+		access_log_writer:      os.Stdout,
+		unix_socket:            lisette.MakeOptionNone[string](),
+		listener:               lisette.MakeOptionNone[net.Listener](),
+		starting_response:      StartingResponse{status: http.StatusServiceUnavailable, body: "server starting"},
+		forwarded_proto_header: FORWARDED_PROTO,
+		client_ip_header:       FORWARDED_FOR,
+		rate_limit:             lisette.MakeOptionNone[RateLimit](),
+		rate_limit_key:         lisette.MakeOptionNone[RateLimitKeyFunc](),
+		panic_observer:         lisette.MakeOptionNone[PanicObserver](),
+		shutdown_signals:       []os.Signal{os.Interrupt, syscall.SIGTERM},
+		request_id_headers:     []string{REQUEST_ID_HEADER},
+		compression_level:      lisette.MakeOptionNone[int](),
+		compression_min_size:   DEFAULT_COMPRESSION_MIN_SIZE,
+		compression_skip_types: default_compression_skip_types,
 	}
 }
 
@@ -75,6 +202,39 @@ func WithMetricsHandler(h http.Handler) ServerOption {
 	}
 }
 
+func WithMetrics(path string) ServerOption {
+	return func(c *Config) {
+		c.metrics = lisette.MakeOptionSome(default_metrics_setup(path))
+	}
+}
+
+func WithMetricsRegistry(reg *prometheus.Registry, path string) ServerOption {
+	return func(c *Config) {
+		c.metrics = lisette.MakeOptionSome(registry_metrics_setup(reg, path))
+	}
+}
+
+func WithMiddleware(mws ...func(http.Handler) http.Handler) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.middleware = append(c.middleware, mws...)
+	}
+}
+
+func WithHandlerMiddleware(mw func(http.Handler) http.Handler) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.handler_middleware = append(c.handler_middleware, mw)
+	}
+}
+
+func WithLogContext(f LogContextFunc) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.log_context = append(c.log_context, f)
+	}
+}
+
 func WithLogger(logger *slog.Logger) ServerOption {
 	return func(c *Config) {
 		c.logger = logger
@@ -105,6 +265,12 @@ func WithIdleTimeout(d time.Duration) ServerOption {
 	}
 }
 
+func WithMaxHeaderBytes(n int) ServerOption {
+	return func(c *Config) {
+		c.max_header_bytes = n
+	}
+}
+
 func WithShutdownTimeout(d time.Duration) ServerOption {
 	return func(c *Config) {
 		c.shutdown_timeout = d
@@ -123,8 +289,24 @@ func WithReadinessCheck(name string, check CheckFunc) ServerOption {
 
 func WithShutdownHook(hook ShutdownHook) ServerOption {
 	return func(c *Config) {
+		name := hook_name(len(c.shutdown_hooks))
 		ref_1 := c
-		ref_1.shutdown_hooks = append(c.shutdown_hooks, hook)
+		ref_1.shutdown_hooks = append(c.shutdown_hooks, NamedHook{name: name, hook: hook, timeout: 0})
+	}
+}
+
+func WithShutdownHookTimeout(timeout time.Duration, hook ShutdownHook) ServerOption {
+	return func(c *Config) {
+		name := hook_name(len(c.shutdown_hooks))
+		ref_1 := c
+		ref_1.shutdown_hooks = append(c.shutdown_hooks, NamedHook{name: name, hook: hook, timeout: timeout})
+	}
+}
+
+func WithNamedShutdownHook(name string, hook ShutdownHook) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.shutdown_hooks = append(c.shutdown_hooks, NamedHook{name: name, hook: hook, timeout: 0})
 	}
 }
 
@@ -154,3 +336,451 @@ func WithReadinessPath(path string) ServerOption {
 		c.readiness_path = path
 	}
 }
+
+func WithHealthEndpoint(path string) ServerOption {
+	return func(c *Config) {
+		c.health_path = lisette.MakeOptionSome(path)
+	}
+}
+
+func WithAccessLog() ServerOption {
+	return func(c *Config) {
+		c.access_log = true
+	}
+}
+
+func WithAccessLogIgnorePaths(paths ...string) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.access_log_ignore_paths = append(c.access_log_ignore_paths, paths...)
+	}
+}
+
+func WithAccessLogSkip(skip func(*http.Request) bool) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.access_log_skips = append(c.access_log_skips, skip)
+	}
+}
+
+func WithAccessLogFormat(format AccessLogFormat) ServerOption {
+	return func(c *Config) {
+		c.access_log = true
+		c.access_log_format = format
+	}
+}
+
+func WithAccessLogWriter(w io.Writer) ServerOption {
+	return func(c *Config) {
+		c.access_log_writer = w
+	}
+}
+
+func WithClock(clock Clock) ServerOption {
+	return func(c *Config) {
+		c.clock = clock
+	}
+}
+
+func WithRequireHTTPS(behavior HTTPSBehavior) ServerOption {
+	return func(c *Config) {
+		c.require_https = lisette.MakeOptionSome[HTTPSBehavior](behavior)
+	}
+}
+
+func WithTrustedProxies(prefixes ...netip.Prefix) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.trusted_proxies = append(c.trusted_proxies, prefixes...)
+	}
+}
+
+func WithForwardedProtoHeader(header string, proxies ...netip.Prefix) ServerOption {
+	return func(c *Config) {
+		c.forwarded_proto_header = header
+		c.trusted_proxies = append(c.trusted_proxies, proxies...)
+	}
+}
+
+func WithClientIPHeader(header string, proxies ...netip.Prefix) ServerOption {
+	return func(c *Config) {
+		c.client_ip_header = header
+		c.trusted_proxies = append(c.trusted_proxies, proxies...)
+	}
+}
+
+func WithRateLimit(rps float64, burst int) ServerOption {
+	return func(c *Config) {
+		c.rate_limit = lisette.MakeOptionSome(RateLimit{rps: rps, burst: burst})
+	}
+}
+
+func WithRateLimitKey(key RateLimitKeyFunc) ServerOption {
+	return func(c *Config) {
+		c.rate_limit_key = lisette.MakeOptionSome[RateLimitKeyFunc](key)
+	}
+}
+
+func WithUnknownRouteLabel(label string) ServerOption {
+	return func(c *Config) {
+		c.unknown_route_label = label
+	}
+}
+
+func WithWarmup(warmup WarmupFunc) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.warmups = append(c.warmups, warmup)
+	}
+}
+
+func WithWarmupRequired() ServerOption {
+	return func(c *Config) {
+		c.warmup_required = true
+	}
+}
+
+func WithAcceptErrorHandler(handler AcceptErrorHandler) ServerOption {
+	return func(c *Config) {
+		c.accept_error_handler = lisette.MakeOptionSome[AcceptErrorHandler](handler)
+	}
+}
+
+func WithBaseContext(f BaseContextFunc) ServerOption {
+	return func(c *Config) {
+		c.base_context = lisette.MakeOptionSome[BaseContextFunc](f)
+	}
+}
+
+func WithListenerName(name string) ServerOption {
+	return func(c *Config) {
+		c.listener_name = name
+	}
+}
+
+func WithShutdownResponse(status int, body string) ServerOption {
+	return func(c *Config) {
+		c.shutdown_response = lisette.MakeOptionSome[ShutdownResponse](ShutdownResponse{
+			status: status,
+			body:   body,
+		})
+	}
+}
+
+func WithStartingResponse(status int, body string) ServerOption {
+	return func(c *Config) {
+		c.starting_response = StartingResponse{status: status, body: body}
+	}
+}
+
+func WithHookSlowThreshold(fraction float64) ServerOption {
+	return func(c *Config) {
+		c.hook_slow_threshold = fraction
+	}
+}
+
+func WithConcurrentShutdownHooks(max int) ServerOption {
+	return func(c *Config) {
+		c.hook_concurrency = max
+	}
+}
+
+func WithPreShutdownDelay(d time.Duration) ServerOption {
+	return func(c *Config) {
+		c.pre_shutdown_delay = d
+	}
+}
+
+func WithCertificates(certs ...tls.Certificate) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.certificates = append(c.certificates, certs...)
+	}
+}
+
+func WithShutdownProgress(ch chan ShutdownPhase) ServerOption {
+	return func(c *Config) {
+		c.shutdown_progress = lisette.MakeOptionSome[chan ShutdownPhase](ch)
+	}
+}
+
+func WithALPNHandler(proto string, fn ALPNHandler) ServerOption {
+	return func(c *Config) {
+		c.alpn_handlers[proto] = fn
+	}
+}
+
+func WithAutoHead() ServerOption {
+	return func(c *Config) {
+		c.auto_head = true
+	}
+}
+
+func WithRequestObserver(fn RequestObserver) ServerOption {
+	return func(c *Config) {
+		c.request_observer = lisette.MakeOptionSome[RequestObserver](fn)
+	}
+}
+
+func WithHookBudget(d time.Duration) ServerOption {
+	return func(c *Config) {
+		c.hook_budget = d
+	}
+}
+
+func WithEmbeddedMode() ServerOption {
+	return func(c *Config) {
+		c.embedded = true
+	}
+}
+
+func WithStrictRequestValidation() ServerOption {
+	return func(c *Config) {
+		c.strict_requests = true
+	}
+}
+
+func WithStartupHook(hook StartupHook) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.startup_hooks = append(c.startup_hooks, hook)
+	}
+}
+
+func WithPostBindHook(hook PostBindHook) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.post_bind_hooks = append(c.post_bind_hooks, hook)
+	}
+}
+
+func WithInFlightTracking() ServerOption {
+	return func(c *Config) {
+		c.track_in_flight = true
+	}
+}
+
+func WithFeatureFlags(provider FlagProvider) ServerOption {
+	return func(c *Config) {
+		c.flag_provider = lisette.MakeOptionSome[FlagProvider](provider)
+	}
+}
+
+func WithErrorLogger(l *log.Logger) ServerOption {
+	return func(c *Config) {
+		c.error_logger = lisette.MakeOptionSome(l)
+	}
+}
+
+func WithRequireAccept(media_types ...string) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.accept_types = append(c.accept_types, media_types...)
+	}
+}
+
+func WithUpstreamDeadline(header string) ServerOption {
+	return func(c *Config) {
+		c.deadline_header = lisette.MakeOptionSome(header)
+	}
+}
+
+func WithRecovery() ServerOption {
+	return func(c *Config) {
+		c.recovery = true
+	}
+}
+
+func WithShutdownSignals(sigs ...os.Signal) ServerOption {
+	return func(c *Config) {
+		if len(sigs) > 0 {
+			c.shutdown_signals = slices.Clone(sigs)
+		}
+	}
+}
+
+func WithPanicObserver(observe PanicObserver) ServerOption {
+	return func(c *Config) {
+		c.recovery = true
+		c.panic_observer = lisette.MakeOptionSome(observe)
+	}
+}
+
+func WithPanicRateAlert(threshold int, window time.Duration) ServerOption {
+	return func(c *Config) {
+		c.recovery = true
+		c.panic_threshold = threshold
+		c.panic_window = window
+	}
+}
+
+func WithOptionsHandler(describe OptionsFunc) ServerOption {
+	return func(c *Config) {
+		c.options_handler = lisette.MakeOptionSome(describe)
+	}
+}
+
+func WithIdleTimeoutJitter(fraction float64) ServerOption {
+	return func(c *Config) {
+		c.idle_jitter = max(0.0, min(fraction, 0.5))
+	}
+}
+
+func WithTotalRequestTimeout(d time.Duration) ServerOption {
+	return func(c *Config) {
+		c.total_request_timeout = d
+	}
+}
+
+func WithRequestTimeout(d time.Duration) ServerOption {
+	return func(c *Config) {
+		c.request_timeout = d
+	}
+}
+
+func WithRequestTimeoutExempt(paths ...string) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.request_timeout_exempt = append(c.request_timeout_exempt, paths...)
+	}
+}
+
+func WithConnState(hook ConnStateFunc) ServerOption {
+	return func(c *Config) {
+		ref_1 := c
+		ref_1.conn_state_hooks = append(c.conn_state_hooks, hook)
+	}
+}
+
+func WithConnMetrics() ServerOption {
+	return func(c *Config) {
+		c.conn_metrics = true
+	}
+}
+
+func WithShutdownCancelThreshold(d time.Duration) ServerOption {
+	return func(c *Config) {
+		c.cancel_threshold = d
+		c.track_in_flight = true
+	}
+}
+
+func WithRequestID() ServerOption {
+	return func(c *Config) {
+		c.request_id = lisette.MakeOptionSome[RequestIDGenerator](NewRequestID)
+	}
+}
+
+func WithRequestIDGenerator(generate RequestIDGenerator) ServerOption {
+	return func(c *Config) {
+		c.request_id = lisette.MakeOptionSome(generate)
+	}
+}
+
+func WithRequestIDHeader(names ...string) ServerOption {
+	return func(c *Config) {
+		if len(names) > 0 {
+			c.request_id_headers = slices.Clone(names)
+		}
+		if c.request_id.Tag == lisette.OptionNone {
+			c.request_id = lisette.MakeOptionSome[RequestIDGenerator](NewRequestID)
+		}
+	}
+}
+
+func WithTLS(cert_file string, key_file string) ServerOption {
+	return func(c *Config) {
+		c.key_pairs = append(c.key_pairs, KeyPairFiles{cert_file: cert_file, key_file: key_file})
+	}
+}
+
+func WithStreamingSupport() ServerOption {
+	return func(c *Config) {
+		c.streaming = true
+	}
+}
+
+func WithCompression(level int) ServerOption {
+	return func(c *Config) {
+		c.compression_level = lisette.MakeOptionSome[int](level)
+	}
+}
+
+func WithCompressionMinSize(n int) ServerOption {
+	return func(c *Config) {
+		c.compression_min_size = n
+		if c.compression_level.Tag == lisette.OptionNone {
+			c.compression_level = lisette.MakeOptionSome[int](flate.DefaultCompression)
+		}
+	}
+}
+
+func WithCompressionSkipTypes(types ...string) ServerOption {
+	return func(c *Config) {
+		c.compression_skip_types = slices.Clone(types)
+		if c.compression_level.Tag == lisette.OptionNone {
+			c.compression_level = lisette.MakeOptionSome[int](flate.DefaultCompression)
+		}
+	}
+}
+
+func WithAllowedMethods(prefix string, methods ...string) ServerOption {
+	return func(c *Config) {
+		c.allowed_methods[prefix] = method_rule(methods)
+	}
+}
+
+func WithTLSConfig(cfg *tls.Config) ServerOption {
+	return func(c *Config) {
+		c.custom_tls = lisette.MakeOptionSome(cfg)
+	}
+}
+
+func WithHTTPRedirect(addr string) ServerOption {
+	return func(c *Config) {
+		c.redirect_addr = lisette.MakeOptionSome(addr)
+	}
+}
+
+func WithAutocert(cache_dir string, domains ...string) ServerOption {
+	return func(c *Config) {
+		c.autocert = lisette.MakeOptionSome(autocert_manager(cache_dir, domains))
+	}
+}
+
+func WithClientCAs(pool *x509.CertPool, auth tls.ClientAuthType) ServerOption {
+	return func(c *Config) {
+		c.client_cas = lisette.MakeOptionSome(pool)
+		c.client_auth = auth
+	}
+}
+
+func WithConfigFile(path string, on_reload ConfigReloader) ServerOption {
+	return func(c *Config) {
+		c.config_file = lisette.MakeOptionSome(ConfigFile{path: path, on_reload: on_reload})
+	}
+}
+
+func WithListener(l net.Listener) ServerOption {
+	return func(c *Config) {
+		c.listener = lisette.MakeOptionSome(l)
+	}
+}
+
+func WithListenConfig(lc net.ListenConfig) ServerOption {
+	return func(c *Config) {
+		c.listen_config = lc
+	}
+}
+
+func WithReusePort() ServerOption {
+	return func(c *Config) {
+		c.reuse_port = true
+	}
+}
+
+func WithUnixSocket(path string) ServerOption {
+	return func(c *Config) {
+		c.unix_socket = lisette.MakeOptionSome(path)
+	}
+}
@@ -0,0 +1,44 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestOptionsStar(t *testing.T) {
+	var logs logSink
+	var handled []string
+	_, addr := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithOptionsHandler(func() ([]string, map[string]string) {
+			return []string{"GET", "POST", "OPTIONS"}, map[string]string{"Accept-Patch": "application/json"}
+		}),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handled = append(handled, r.Method+" "+r.RequestURI)
+		})),
+	)
+
+	resp := rawRequest(t, addr, "OPTIONS * HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Allow"); got != "GET, POST, OPTIONS" {
+		t.Errorf("Allow = %q, want GET, POST, OPTIONS", got)
+	}
+	if got := resp.Header.Get("Accept-Patch"); got != "application/json" {
+		t.Errorf("Accept-Patch = %q, want application/json", got)
+	}
+
+	// A preflight names a path and goes to the handler.
+	resp = rawRequest(t, addr, "OPTIONS /things HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	resp.Body.Close()
+	if resp.Header.Get("Allow") != "" {
+		t.Errorf("OPTIONS /things got Allow %q from the OPTIONS * handler", resp.Header.Get("Allow"))
+	}
+	if len(handled) != 1 || handled[0] != "OPTIONS /things" {
+		t.Errorf("handler saw %v, want only OPTIONS /things", handled)
+	}
+}
@@ -0,0 +1,99 @@
+// Package otelhttpserver adds OpenTelemetry tracing to an httpserver.Server.
+//
+// It is a separate package so that servers without tracing do not link
+// OpenTelemetry: only programs importing it do.
+package otelhttpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/banan-tech/httpserver"
+)
+
+// WithOTelTracing traces every request with a server span from tp.
+//
+// The span continues the trace of an incoming W3C traceparent header (and
+// carries its baggage), whatever the global propagator is. It is named from
+// the method, then from the route once the handler's http.ServeMux has matched
+// one (e.g. "GET /users/{id}"), and records the response status, a 5xx status
+// or a handler panic marking it failed. Server log records made with a request
+// context, such as access log lines, get trace_id and span_id attributes.
+func WithOTelTracing(tp trace.TracerProvider) httpserver.ServerOption {
+	propagator := propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+	tracing := httpserver.WithMiddleware(func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(next, "http.server",
+			otelhttp.WithTracerProvider(tp),
+			otelhttp.WithPropagators(propagator),
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return r.Method
+			}),
+		)
+	})
+	routes := httpserver.WithHandlerMiddleware(routeMiddleware)
+	logs := httpserver.WithLogContext(traceAttrs)
+	return func(c *httpserver.Config) {
+		tracing(c)
+		routes(c)
+		logs(c)
+	}
+}
+
+// routeMiddleware names the request's span after the route its handler
+// matched, which is only known once the handler has returned, and records a
+// panic on the span before passing it on to the recovery middleware (or
+// net/http). The pattern the server mounted the handler at is inherited by r,
+// so only a pattern set by the handler's own router counts.
+func routeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		mounted := r.Pattern
+		defer func() {
+			if route := routeOf(r.Pattern); route != "" && r.Pattern != mounted {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(attribute.String("http.route", route))
+			}
+			if v := recover(); v != nil {
+				if v != http.ErrAbortHandler {
+					span.RecordError(fmt.Errorf("panic: %v", v), trace.WithStackTrace(true))
+					span.SetStatus(codes.Error, "panic")
+				}
+				panic(v)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeOf returns the path part of a ServeMux pattern such as
+// "GET example.com/users/{id}", or "" for none.
+func routeOf(pattern string) string {
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		return pattern[i:]
+	}
+	return ""
+}
+
+// traceAttrs returns the trace and span IDs of the span in ctx, if any.
+func traceAttrs(ctx context.Context) []slog.Attr {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String("trace_id", sc.TraceID().String()),
+		slog.String("span_id", sc.SpanID().String()),
+	}
+}
@@ -0,0 +1,70 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestRateLimit(t *testing.T) {
+	var logs logSink
+	clock := newFakeClock()
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithClock(clock),
+		httpserver.WithRateLimit(0.5, 2),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+	})
+	serve := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	const client = "192.0.2.1:1234"
+	expectLimited := func(retryAfter string) {
+		t.Helper()
+		rec := serve(client)
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want 429", rec.Code)
+		}
+		if got := rec.Header().Get("Retry-After"); got != retryAfter {
+			t.Errorf("Retry-After = %q, want %q", got, retryAfter)
+		}
+		if !strings.Contains(rec.Body.String(), "too many requests") {
+			t.Errorf("body = %q", rec.Body.String())
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := serve(client); rec.Code != http.StatusOK {
+			t.Fatalf("request %d within the burst = %d, want 200", i+1, rec.Code)
+		}
+	}
+	expectLimited("2")
+	if rec := serve("192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("another client = %d, want 200", rec.Code)
+	}
+
+	clock.Advance(1500 * time.Millisecond)
+	expectLimited("1")
+
+	clock.Advance(500 * time.Millisecond)
+	if rec := serve(client); rec.Code != http.StatusOK {
+		t.Fatalf("after the bucket refilled = %d, want 200", rec.Code)
+	}
+	expectLimited("2")
+
+	clock.Advance(4 * time.Second)
+	for i := 0; i < 2; i++ {
+		if rec := serve(client); rec.Code != http.StatusOK {
+			t.Fatalf("request %d after a full refill = %d, want 200", i+1, rec.Code)
+		}
+	}
+	expectLimited("2")
+}
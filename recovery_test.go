@@ -0,0 +1,71 @@
+package httpserver_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRecoveryLogCarriesRequestID(t *testing.T) {
+	var logs logSink
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithRecovery(),
+		httpserver.WithRequestID(),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			httpserver.AddLogAttrs(r.Context(), slog.String("tenant", "acme"))
+			panic("boom")
+		})),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/explode", nil)
+	req.Header.Set("X-Request-ID", "req-7")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	lines := logs.records(t, "panic recovered")
+	if len(lines) != 1 {
+		t.Fatalf("got %d panic log lines, want 1", len(lines))
+	}
+	want := map[string]any{"request_id": "req-7", "method": "GET", "path": "/explode", "panic": "boom", "tenant": "acme"}
+	for k, v := range want {
+		if lines[0][k] != v {
+			t.Errorf("panic log %s = %v, want %v", k, lines[0][k], v)
+		}
+	}
+}
+
+func TestPanicsCountedInMetrics(t *testing.T) {
+	var logs logSink
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /boom/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithRecovery(),
+		httpserver.WithMetricsRegistry(prometheus.NewRegistry(), "/metrics"),
+		httpserver.WithHandler(mux),
+	})
+
+	for _, path := range []string{"/boom/1", "/boom/2"} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("GET %s = %d, want 500", path, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := `http_panics_total{route="GET /boom/{id}"} 2`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("metrics lack %s", want)
+	}
+}
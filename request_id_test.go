@@ -0,0 +1,89 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestRequestIDInboundPreserved(t *testing.T) {
+	var logs logSink
+	var seen string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = httpserver.RequestID(r.Context())
+	})
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithAccessLog(),
+		httpserver.WithRequestIDGenerator(func(*http.Request) string {
+			t.Error("generator called for a request carrying an ID")
+			return "generated"
+		}),
+		httpserver.WithHandler(mux),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set("X-Request-ID", "inbound-1")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	if seen != "inbound-1" {
+		t.Errorf("handler saw request ID %q, want inbound-1", seen)
+	}
+	if got := rec.Header().Get("X-Request-ID"); got != "inbound-1" {
+		t.Errorf("echoed X-Request-ID = %q, want inbound-1", got)
+	}
+	lines := logs.records(t, "request")
+	if len(lines) != 1 {
+		t.Fatalf("got %d access log lines, want 1", len(lines))
+	}
+	if lines[0]["request_id"] != "inbound-1" {
+		t.Errorf("logged request_id = %v, want inbound-1", lines[0]["request_id"])
+	}
+	if lines[0]["route"] != "GET /users/{id}" {
+		t.Errorf("logged route = %v, want GET /users/{id}", lines[0]["route"])
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	var logs logSink
+	var seen string
+	var observed httpserver.RequestInfo
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = httpserver.RequestID(r.Context())
+	})
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithAccessLog(),
+		httpserver.WithRequestIDGenerator(func(*http.Request) string { return "custom-42" }),
+		httpserver.WithRequestObserver(func(info httpserver.RequestInfo) { observed = info }),
+		httpserver.WithHandler(mux),
+	})
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+
+	if seen != "custom-42" {
+		t.Errorf("handler saw request ID %q, want custom-42", seen)
+	}
+	if got := rec.Header().Get("X-Request-ID"); got != "custom-42" {
+		t.Errorf("echoed X-Request-ID = %q, want custom-42", got)
+	}
+	lines := logs.records(t, "request")
+	if len(lines) != 1 {
+		t.Fatalf("got %d access log lines, want 1", len(lines))
+	}
+	if lines[0]["request_id"] != "custom-42" {
+		t.Errorf("logged request_id = %v, want custom-42", lines[0]["request_id"])
+	}
+	if lines[0]["route"] != "GET /users/{id}" {
+		t.Errorf("logged route = %v, want GET /users/{id}", lines[0]["route"])
+	}
+	if observed.Pattern != "GET /users/{id}" {
+		t.Errorf("observed pattern = %q, want GET /users/{id}", observed.Pattern)
+	}
+}
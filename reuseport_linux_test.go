@@ -0,0 +1,29 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestReusePortBindsTwice(t *testing.T) {
+	var logs logSink
+	opts := func(addr string) []httpserver.ServerOption {
+		return []httpserver.ServerOption{
+			httpserver.WithLogger(logs.logger()),
+			httpserver.WithAddr(addr),
+			httpserver.WithReusePort(),
+			httpserver.WithHandler(http.NotFoundHandler()),
+		}
+	}
+	first := runServer(t, opts("127.0.0.1:0")...)
+	addr, ok := first.Addr()
+	if !ok {
+		t.Fatal("first server has no address")
+	}
+	second := runServer(t, opts(addr.String())...)
+	if got, _ := second.Addr(); got.String() != addr.String() {
+		t.Errorf("second server bound %v, want %v", got, addr)
+	}
+}
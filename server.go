@@ -4,26 +4,88 @@ package httpserver
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	lisette "github.com/ivov/lisette/prelude"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"sync"
 	"sync/atomic"
-	"syscall"
 	"time"
 )
 
 type ShutdownHook func(context.Context) error
 
+type WarmupFunc func(context.Context) error
+
+type BaseContextFunc func(net.Listener) context.Context
+
+type PostBindHook func() error
+
+type StartupHook func(context.Context) error
+
 type Server struct {
-	srv              *http.Server
-	shutdown_timeout time.Duration
-	ready            *atomic.Bool
-	logger           *slog.Logger
-	shutdown_hooks   []ShutdownHook
+	srv                    *http.Server
+	base                   http.Handler
+	middleware             []func(http.Handler) http.Handler
+	shutdown_timeout       time.Duration
+	ready                  *atomic.Bool
+	readiness              http.Handler
+	checks                 *ReadinessChecks
+	pre_shutdown_delay     time.Duration
+	logger                 *slog.Logger
+	clock                  Clock
+	shutdown_hooks         []NamedHook
+	timeouts               *LiveTimeouts
+	ctx                    context.Context
+	cancel                 context.CancelFunc
+	stop                   context.CancelFunc
+	warmups                []WarmupFunc
+	warmup_required        bool
+	failed                 *atomic.Pointer[error]
+	hook_slow_threshold    float64
+	hook_concurrency       int
+	accept_error_handler   lisette.Option[AcceptErrorHandler]
+	shutdown_progress      lisette.Option[chan ShutdownPhase]
+	hook_budget            time.Duration
+	embedded               bool
+	start_time             *atomic.Pointer[time.Time]
+	post_bind_hooks        []PostBindHook
+	startup_hooks          []StartupHook
+	tracker                lisette.Option[*RequestTracker]
+	idle_jitter            float64
+	total_request_timeout  time.Duration
+	stats                  *ServerStats
+	shutdown_signal        *atomic.Pointer[string]
+	record                 *atomic.Pointer[ShutdownRecord]
+	cancel_threshold       time.Duration
+	key_pairs              []KeyPairFiles
+	redirect_srv           lisette.Option[*http.Server]
+	config_file            lisette.Option[ConfigFile]
+	unix_socket            lisette.Option[string]
+	listener               lisette.Option[net.Listener]
+	bound_addr             *atomic.Pointer[net.Addr]
+	listening              chan struct{}
+	stopped                chan struct{}
+	stop_err               *atomic.Pointer[error]
+	stop_once              *atomic.Bool
+	shut_down              chan struct{}
+	shutdown_err           *atomic.Pointer[error]
+	shutdown_once          *atomic.Bool
+	close_once             *sync.Once
+	starting               *atomic.Bool
+	trusted_proxies        []netip.Prefix
+	forwarded_proto_header string
+	shutdown_signals       []os.Signal
+	listen_config          net.ListenConfig
+	reuse_port             bool
+	conns                  lisette.Option[*ConnTracker]
 }
 
 func New(options []ServerOption) *Server {
@@ -32,46 +94,409 @@ func New(options []ServerOption) *Server {
 	for _, o := range options {
 		o(&cfg)
 	}
+	cfg.logger = context_logger(cfg.logger, cfg.log_context)
+	subject_1 := cfg.autocert
+	if subject_1.Tag == lisette.OptionSome {
+		m := subject_1.SomeVal
+		if cfg.custom_tls.Tag != lisette.OptionSome {
+			cfg.custom_tls = lisette.MakeOptionSome(autocert_tls_config(m, cfg.logger))
+		}
+		if cfg.redirect_addr.Tag != lisette.OptionSome {
+			cfg.redirect_addr = lisette.MakeOptionSome(":80")
+		}
+	}
+	if cfg.custom_tls.Tag == lisette.OptionSome {
+		cfg.key_pairs = ([]KeyPairFiles)(nil)
+	}
+	if cfg.max_header_bytes < 0 {
+		panic(fmt.Sprintf("httpserver: negative max header bytes %d", cfg.max_header_bytes))
+	}
+	warming := len(cfg.startup_hooks) > 0 || len(cfg.warmups) > 0
 	ready := &atomic.Bool{}
-	ready.Store(true)
+	ready.Store(!warming)
+	starting := &atomic.Bool{}
+	starting.Store(warming)
+	ctx, cancel := context.WithCancel(context.Background())
+	stop_ctx, stop := context.WithCancel(context.Background())
+	checks := &ReadinessChecks{checks: cfg.readiness_checks}
+	readiness := readiness_handler(ready, checks, cfg.logger)
 	mux := http.NewServeMux()
 	if !cfg.disable_default_probes {
 		mux.HandleFunc(cfg.liveness_path, liveness_handler)
-		mux.Handle(cfg.readiness_path, readiness_handler(ready, cfg.readiness_checks, cfg.logger))
+		mux.Handle(cfg.readiness_path, readiness)
+	}
+	subject_2 := cfg.metrics_handler
+	if subject_2.Tag == lisette.OptionSome {
+		mux.Handle("/_metrics", subject_2.SomeVal)
+	}
+	metrics := lisette.MakeOptionNone[*HTTPMetrics]()
+	subject_3 := cfg.metrics
+	if subject_3.Tag == lisette.OptionSome {
+		m := subject_3.SomeVal
+		mux.Handle(m.path, m.handler)
+		metrics = lisette.MakeOptionSome(http_metrics(m.registerer, cfg.logger))
+	}
+	subject_4 := cfg.handler
+	if subject_4.Tag == lisette.OptionSome {
+		mux.Handle("/", app_middleware(&cfg, metrics, ctx, starting, subject_4.SomeVal))
+	}
+	timeouts := &LiveTimeouts{}
+	var root http.Handler = live_timeouts_middleware(timeouts)(record_route(mux))
+	subject_5 := cfg.health_path
+	if subject_5.Tag == lisette.OptionSome {
+		path := subject_5.SomeVal
+		if path == "" {
+			panic("httpserver: health endpoint path is empty")
+		}
+		root = health_middleware(path)(root)
+	}
+	subject_6 := cfg.options_handler
+	if subject_6.Tag == lisette.OptionSome {
+		root = options_star_middleware(subject_6.SomeVal)(root)
+	}
+	if cfg.strict_requests {
+		root = strict_validation_middleware(root)
+	}
+	subject_7 := cfg.request_observer
+	if subject_7.Tag == lisette.OptionSome {
+		root = observer_middleware(subject_7.SomeVal, cfg.clock, cfg.unknown_route_label)(root)
+	}
+	subject_8 := metrics
+	if subject_8.Tag == lisette.OptionSome {
+		root = metrics_middleware(subject_8.SomeVal, cfg.clock, cfg.unknown_route_label)(root)
+	}
+	if cfg.access_log {
+		root = access_log_middleware(cfg.logger, cfg.access_log_ignore_paths, cfg.access_log_skips, cfg.clock, cfg.unknown_route_label, cfg.access_log_format, &LineWriter{w: cfg.access_log_writer})(root)
+	}
+	subject_9 := cfg.request_id
+	if subject_9.Tag == lisette.OptionSome {
+		root = assign_request_id(subject_9.SomeVal, cfg.request_id_headers)(root)
+	}
+	root = route_middleware(root)
+	stats := &ServerStats{}
+	root = count_middleware(stats)(root)
+	tracker := lisette.MakeOptionNone[*RequestTracker]()
+	if cfg.track_in_flight {
+		t := &RequestTracker{clock: cfg.clock, active: make(map[uint64]TrackedRequest)}
+		root = in_flight_middleware(t)(root)
+		tracker = lisette.MakeOptionSome(t)
+	}
+	base := root
+	root = chain(base, cfg.middleware)
+	opt_10 := lisette.MakeOptionSome[http.Handler](root)
+	var unwrap_11 http.Handler
+	if opt_10.Tag == lisette.OptionSome {
+		unwrap_11 = opt_10.SomeVal
+	}
+	opt_12 := lisette.MakeOptionSome(error_log(&cfg, ctx))
+	var unwrap_13 *log.Logger
+	if opt_12.Tag == lisette.OptionSome {
+		unwrap_13 = opt_12.SomeVal
+	}
+	opt_14 := lisette.MakeOptionSome(base_context(cfg.base_context, stop_ctx))
+	var unwrap_15 func(net.Listener) context.Context
+	if opt_14.Tag == lisette.OptionSome {
+		unwrap_15 = opt_14.SomeVal
+	}
+	opt_16 := lisette.MakeOptionSome(listener_context(cfg.listener_name))
+	var unwrap_17 func(context.Context, net.Conn) context.Context
+	if opt_16.Tag == lisette.OptionSome {
+		unwrap_17 = opt_16.SomeVal
+	}
+	srv := &http.Server{
+		Addr:                         cfg.addr,
+		Handler:                      unwrap_11,
+		ReadHeaderTimeout:            cfg.read_header_timeout,
+		ReadTimeout:                  cfg.read_timeout,
+		WriteTimeout:                 cfg.write_timeout,
+		IdleTimeout:                  cfg.idle_timeout,
+		MaxHeaderBytes:               cfg.max_header_bytes,
+		ErrorLog:                     unwrap_13,
+		BaseContext:                  unwrap_15,
+		ConnContext:                  unwrap_17,
+		TLSConfig:                    tls_config(cfg.custom_tls, cfg.certificates, cfg.key_pairs, cfg.alpn_handlers),
+		DisableGeneralOptionsHandler: cfg.options_handler.Tag == lisette.OptionSome,
+	}
+	configure_alpn(srv, cfg.alpn_handlers, stop_ctx)
+	raw_18 := srv.TLSConfig
+	option_19 := lisette.OptionFromNilable[*tls.Config](raw_18, raw_18 == nil)
+	if option_19.Tag == lisette.OptionSome {
+		tc := option_19.SomeVal
+		subject_20 := cfg.client_cas
+		if subject_20.Tag == lisette.OptionSome {
+			tc.ClientCAs = subject_20.SomeVal
+			tc.ClientAuth = cfg.client_auth
+		}
+	}
+	conns := lisette.MakeOptionNone[*ConnTracker]()
+	if cfg.conn_metrics {
+		conns = lisette.MakeOptionSome(&ConnTracker{states: make(map[net.Conn]http.ConnState)})
+	}
+	conn_hooks := cfg.conn_state_hooks
+	srv.ConnState = func(conn net.Conn, state http.ConnState) {
+		stats.track_conn(state)
+		mark_conn(conn, state)
+		subject_21 := conns
+		if subject_21.Tag == lisette.OptionSome {
+			subject_21.SomeVal.track(conn, state)
+		}
+		for _, hook := range conn_hooks {
+			hook(conn, state)
+		}
+	}
+	redirect_srv := lisette.MakeOptionNone[*http.Server]()
+	subject_22 := cfg.redirect_addr
+	if subject_22.Tag == lisette.OptionSome {
+		addr := subject_22.SomeVal
+		if srv.TLSConfig != nil {
+			redirect := https_redirect_handler(cfg.addr)
+			subject_23 := cfg.autocert
+			if subject_23.Tag == lisette.OptionSome {
+				redirect = subject_23.SomeVal.HTTPHandler(redirect)
+			}
+			opt_24 := lisette.MakeOptionSome(redirect)
+			var unwrap_25 http.Handler
+			if opt_24.Tag == lisette.OptionSome {
+				unwrap_25 = opt_24.SomeVal
+			}
+			opt_26 := lisette.MakeOptionSome(error_log(&cfg, ctx))
+			var unwrap_27 *log.Logger
+			if opt_26.Tag == lisette.OptionSome {
+				unwrap_27 = opt_26.SomeVal
+			}
+			redirect_srv = lisette.MakeOptionSome(&http.Server{Addr: addr, Handler: unwrap_25, ReadHeaderTimeout: cfg.read_header_timeout, IdleTimeout: cfg.idle_timeout, MaxHeaderBytes: cfg.max_header_bytes, ErrorLog: unwrap_27})
+		}
+	}
+	return &Server{
+		srv:                    srv,
+		base:                   base,
+		middleware:             cfg.middleware,
+		shutdown_timeout:       cfg.shutdown_timeout,
+		ready:                  ready,
+		readiness:              readiness,
+		checks:                 checks,
+		pre_shutdown_delay:     cfg.pre_shutdown_delay,
+		logger:                 cfg.logger,
+		clock:                  cfg.clock,
+		shutdown_hooks:         cfg.shutdown_hooks,
+		timeouts:               timeouts,
+		ctx:                    ctx,
+		cancel:                 cancel,
+		stop:                   stop,
+		warmups:                cfg.warmups,
+		warmup_required:        cfg.warmup_required,
+		failed:                 &atomic.Pointer[error]{},
+		hook_slow_threshold:    cfg.hook_slow_threshold,
+		hook_concurrency:       cfg.hook_concurrency,
+		accept_error_handler:   cfg.accept_error_handler,
+		shutdown_progress:      cfg.shutdown_progress,
+		hook_budget:            cfg.hook_budget,
+		embedded:               cfg.embedded,
+		start_time:             &atomic.Pointer[time.Time]{},
+		post_bind_hooks:        cfg.post_bind_hooks,
+		startup_hooks:          cfg.startup_hooks,
+		tracker:                tracker,
+		idle_jitter:            cfg.idle_jitter,
+		total_request_timeout:  cfg.total_request_timeout,
+		stats:                  stats,
+		shutdown_signal:        &atomic.Pointer[string]{},
+		record:                 &atomic.Pointer[ShutdownRecord]{},
+		cancel_threshold:       cfg.cancel_threshold,
+		key_pairs:              cfg.key_pairs,
+		redirect_srv:           redirect_srv,
+		config_file:            cfg.config_file,
+		unix_socket:            cfg.unix_socket,
+		listener:               cfg.listener,
+		bound_addr:             &atomic.Pointer[net.Addr]{},
+		listening:              make(chan struct{}),
+		stopped:                make(chan struct{}),
+		stop_err:               &atomic.Pointer[error]{},
+		stop_once:              &atomic.Bool{},
+		shut_down:              make(chan struct{}),
+		shutdown_err:           &atomic.Pointer[error]{},
+		shutdown_once:          &atomic.Bool{},
+		close_once:             &sync.Once{},
+		starting:               starting,
+		trusted_proxies:        cfg.trusted_proxies,
+		forwarded_proto_header: cfg.forwarded_proto_header,
+		shutdown_signals:       cfg.shutdown_signals,
+		listen_config:          cfg.listen_config,
+		reuse_port:             cfg.reuse_port,
+		conns:                  conns,
+	}
+}
+
+func error_log(cfg *Config, server_ctx context.Context) *log.Logger {
+	subject_1 := cfg.error_logger
+	if subject_1.Tag == lisette.OptionSome {
+		return subject_1.SomeVal
+	}
+	return log.New(ErrorLogBridge{logger: cfg.logger, server_ctx: server_ctx}, "", 0)
+}
+
+func hook_name(i int) string {
+	return fmt.Sprintf("hook-%d", i)
+}
+
+type NamedHook struct {
+	name    string
+	hook    ShutdownHook
+	timeout time.Duration
+}
+
+func call_bounded(hook ShutdownHook, ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		ret_2 := hook(ctx)
+		var result_3 lisette.Result[struct{}, error]
+		if ret_2 != nil {
+			result_3 = lisette.MakeResultErr[struct{}, error](ret_2)
+		} else {
+			result_3 = lisette.MakeResultOk[struct{}, error](struct{}{})
+		}
+		subject_1 := result_3
+		if subject_1.Tag == lisette.ResultOk {
+			close(done)
+		} else {
+			_ = lisette.ChannelSend(done, subject_1.ErrVal)
+		}
+	}()
+	expired := ctx.Done()
+	select {
+	case e, ok := <-done:
+		if ok {
+			return e
+		}
+		return nil
+	case _, ok := <-expired:
+		_ = ok
+		return ErrHookTimeout
 	}
-	subject_1 := cfg.metrics_handler
+}
+
+type HookRun struct {
+	duration time.Duration
+	err      lisette.Option[error]
+}
+
+func base_context(user lisette.Option[BaseContextFunc], stop_ctx context.Context) BaseContextFunc {
+	return func(ln net.Listener) context.Context {
+		subject_1 := user
+		if subject_1.Tag != lisette.OptionSome {
+			return stop_ctx
+		}
+		f := subject_1.SomeVal
+		ctx, cancel := context.WithCancel(f(ln))
+		_ = context.AfterFunc(stop_ctx, cancel)
+		return ctx
+	}
+}
+
+func chain(h http.Handler, mws []func(http.Handler) http.Handler) http.Handler {
+	out := h
+	for i := 0; i < len(mws); i++ {
+		out = mws[len(mws)-1-i](out)
+	}
+	return out
+}
+
+func run_on_signals(servers []*Server, signals []os.Signal, run func(context.Context) error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), signals...)
+	defer stop()
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, signals...)
+	defer signal.Stop(sigs)
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		force_on_second_signal(servers, sigs, finished)
+	}()
+	return run(ctx)
+}
+
+func force_on_second_signal(servers []*Server, sigs chan os.Signal, finished chan struct{}) {
+	received := 0
+	for {
+		select {
+		case sig, ok := <-sigs:
+			if ok {
+				received += 1
+				if received < 2 {
+					name := sig.String()
+					for _, s := range servers {
+						s.shutdown_signal.Store(&name)
+					}
+					continue
+				}
+				for _, s := range servers {
+					s.logger.Warn("second signal received, forcing shutdown")
+					s.force_close()
+				}
+				return
+			}
+			return
+		case _, ok := <-finished:
+			_ = ok
+			return
+		}
+	}
+}
+
+func app_middleware(cfg *Config, metrics lisette.Option[*HTTPMetrics], server_ctx context.Context, starting *atomic.Bool, h http.Handler) http.Handler {
+	app := chain(record_route(h), cfg.handler_middleware)
+	if cfg.request_timeout > 0 {
+		app = handler_timeout_middleware(cfg.request_timeout, cfg.request_timeout_exempt)(app)
+	}
+	if cfg.streaming {
+		app = streaming_middleware(server_ctx)(app)
+	}
+	subject_1 := cfg.compression_level
 	if subject_1.Tag == lisette.OptionSome {
-		mux.Handle("/_metrics", subject_1.SomeVal)
+		app = compression_middleware(compression(subject_1.SomeVal, cfg.compression_min_size, cfg.compression_skip_types))(app)
 	}
-	subject_2 := cfg.handler
+	subject_2 := cfg.flag_provider
 	if subject_2.Tag == lisette.OptionSome {
-		mux.Handle("/", subject_2.SomeVal)
+		app = flags_middleware(subject_2.SomeVal)(app)
 	}
-	opt_3 := lisette.MakeOptionSome[http.Handler](mux)
-	var unwrap_4 http.Handler
-	if opt_3.Tag == lisette.OptionSome {
-		unwrap_4 = opt_3.SomeVal
+	if cfg.auto_head {
+		app = auto_head_middleware(app)
 	}
-	opt_5 := lisette.MakeOptionSome(slog.NewLogLogger(cfg.logger.Handler(), slog.LevelError))
-	var unwrap_6 *log.Logger
-	if opt_5.Tag == lisette.OptionSome {
-		unwrap_6 = opt_5.SomeVal
+	subject_3 := cfg.deadline_header
+	if subject_3.Tag == lisette.OptionSome {
+		app = upstream_deadline_middleware(subject_3.SomeVal)(app)
 	}
-	return &Server{
-		srv: &http.Server{
-			Addr:              cfg.addr,
-			Handler:           unwrap_4,
-			ReadHeaderTimeout: cfg.read_header_timeout,
-			ReadTimeout:       cfg.read_timeout,
-			WriteTimeout:      cfg.write_timeout,
-			IdleTimeout:       cfg.idle_timeout,
-			ErrorLog:          unwrap_6,
-		},
-		shutdown_timeout: cfg.shutdown_timeout,
-		ready:            ready,
-		logger:           cfg.logger,
-		shutdown_hooks:   cfg.shutdown_hooks,
+	if len(cfg.accept_types) > 0 {
+		app = require_accept_middleware(cfg.accept_types)(app)
+	}
+	if len(cfg.allowed_methods) > 0 {
+		app = allowed_methods_middleware(cfg.allowed_methods)(app)
+	}
+	subject_4 := cfg.require_https
+	if subject_4.Tag == lisette.OptionSome {
+		app = require_https_middleware(subject_4.SomeVal, cfg.trusted_proxies, cfg.forwarded_proto_header)(app)
+	}
+	subject_5 := cfg.rate_limit
+	if subject_5.Tag == lisette.OptionSome {
+		key := rate_limit_key(cfg.rate_limit_key, cfg.trusted_proxies, cfg.client_ip_header)
+		app = rate_limit_middleware(rate_limiter(subject_5.SomeVal, cfg.clock), key)(app)
+	}
+	if cfg.recovery {
+		app = panic_middleware(recovery(cfg, metrics))(app)
 	}
+	if len(cfg.startup_hooks) > 0 || len(cfg.warmups) > 0 {
+		app = starting_middleware(starting, cfg.starting_response)(app)
+	}
+	return draining_middleware(server_ctx, cfg.shutdown_response)(app)
+}
+
+func (s *Server) Use(mws ...func(http.Handler) http.Handler) {
+	s.middleware = append(s.middleware, mws...)
+	opt_1 := lisette.MakeOptionSome[http.Handler](chain(s.base, s.middleware))
+	var unwrap_2 http.Handler
+	if opt_1.Tag == lisette.OptionSome {
+		unwrap_2 = opt_1.SomeVal
+	}
+	s.srv.Handler = unwrap_2
 }
 
 func (s Server) Handler() http.Handler {
@@ -84,35 +509,469 @@ func (s Server) Handler() http.Handler {
 	return nil
 }
 
+func (s Server) TLSEnabled() bool {
+	return s.srv.TLSConfig != nil
+}
+
+func (s Server) Scheme() string {
+	if s.TLSEnabled() {
+		return "https"
+	}
+	return "http"
+}
+
+func (s Server) RequestScheme(r *http.Request) string {
+	if is_https(r, s.trusted_proxies, s.forwarded_proto_header) {
+		return "https"
+	}
+	return "http"
+}
+
+func (s Server) ShutdownHookCount() int {
+	return len(s.shutdown_hooks)
+}
+
+func (s Server) ShutdownHookNames() []string {
+	names := ([]string)(nil)
+	for _, h := range s.shutdown_hooks {
+		names = append(names, h.name)
+	}
+	return names
+}
+
+func (s Server) StartedAt() time.Time {
+	raw_2 := s.start_time.Load()
+	option_3 := lisette.OptionFromNilable[*time.Time](raw_2, raw_2 == nil)
+	subject_1 := option_3
+	if subject_1.Tag == lisette.OptionSome {
+		return *subject_1.SomeVal
+	}
+	return time.Time{}
+}
+
+func (s Server) Addr() (net.Addr, bool) {
+	raw_2 := s.bound_addr.Load()
+	option_3 := lisette.OptionFromNilable[*net.Addr](raw_2, raw_2 == nil)
+	subject_1 := option_3
+	if subject_1.Tag == lisette.OptionSome {
+		return *subject_1.SomeVal, true
+	}
+	return nil, false
+}
+
+func (s Server) Listening() chan struct{} {
+	return s.listening
+}
+
+func (s Server) AddReadinessCheck(name string, check CheckFunc) {
+	s.checks.add(NamedCheck{name: name, check: check})
+}
+
+func (s Server) ReadinessHandler() http.Handler {
+	return s.readiness
+}
+
+func (s Server) WaitReady(ctx context.Context) error {
+	if s.start_time.Load() != nil {
+		return nil
+	}
+	done := ctx.Done()
+	select {
+	case _, ok := <-s.listening:
+		_ = ok
+		return nil
+	case _, ok := <-s.stopped:
+		_ = ok
+		raw_2 := s.stop_err.Load()
+		option_3 := lisette.OptionFromNilable[*error](raw_2, raw_2 == nil)
+		subject_1 := option_3
+		if subject_1.Tag == lisette.OptionSome {
+			return *subject_1.SomeVal
+		}
+		return http.ErrServerClosed
+	case _, ok := <-done:
+		_ = ok
+		raw_5 := ctx.Err()
+		option_6 := lisette.OptionFromNilable[error](raw_5, lisette.IsNilInterface(raw_5))
+		subject_4 := option_6
+		if subject_4.Tag == lisette.OptionSome {
+			return subject_4.SomeVal
+		}
+		return context.Canceled
+	}
+}
+
+func (s Server) InFlight() []RequestSnapshot {
+	subject_1 := s.tracker
+	if subject_1.Tag == lisette.OptionSome {
+		return subject_1.SomeVal.snapshot()
+	}
+	return []RequestSnapshot{}
+}
+
+func (s Server) ConnStats() ConnStats {
+	subject_1 := s.conns
+	if subject_1.Tag == lisette.OptionSome {
+		return subject_1.SomeVal.snapshot()
+	}
+	return ConnStats{Accepted: 0, Active: 0, Idle: 0, Closed: 0}
+}
+
+func (s Server) SetTimeouts(read time.Duration, write time.Duration, idle time.Duration) {
+	s.timeouts.read.Store(int64(read))
+	s.timeouts.write.Store(int64(write))
+	s.timeouts.idle.Store(int64(idle))
+	s.timeouts.set.Store(true)
+	s.logger.Info("server timeouts updated", "read_timeout", read, "write_timeout", write, "idle_timeout", idle)
+}
+
 func (s *Server) Start() error {
-	s.logger.Info("server starting", "addr", s.srv.Addr)
-	ret_2 := s.srv.ListenAndServe()
-	var result_3 lisette.Result[struct{}, error]
-	if ret_2 != nil {
-		result_3 = lisette.MakeResultErr[struct{}, error](ret_2)
+	result := s.serve()
+	if !s.stop_once.Swap(true) {
+		if result != nil {
+			e := result
+			s.stop_err.Store(&e)
+		}
+		close(s.stopped)
+	}
+	return result
+}
+
+func (s *Server) serve() error {
+	s.logger.Info("server starting", "addr", s.listen_address(), "scheme", s.Scheme())
+	raw_1 := s.srv.TLSConfig
+	option_2 := lisette.OptionFromNilable[*tls.Config](raw_1, raw_1 == nil)
+	if option_2.Tag == lisette.OptionSome {
+		tc := option_2.SomeVal
+		ret_3, err_4 := load_key_pairs(s.key_pairs)
+		var result_5 lisette.Result[[]tls.Certificate, error]
+		if err_4 != nil {
+			result_5 = lisette.MakeResultErr[[]tls.Certificate, error](err_4)
+		} else {
+			result_5 = lisette.MakeResultOk[[]tls.Certificate, error](ret_3)
+		}
+		subject_6 := result_5
+		if subject_6.Tag == lisette.ResultOk {
+			tc.Certificates = append(tc.Certificates, subject_6.OkVal...)
+		} else {
+			e := subject_6.ErrVal
+			s.logger.Error("server error", "error", e.Error())
+			return e
+		}
+		ret_7, err_8 := certificate_domains(tc.Certificates)
+		var result_9 lisette.Result[[]string, error]
+		if err_8 != nil {
+			result_9 = lisette.MakeResultErr[[]string, error](err_8)
+		} else {
+			result_9 = lisette.MakeResultOk[[]string, error](ret_7)
+		}
+		subject_10 := result_9
+		if subject_10.Tag == lisette.ResultOk {
+			s.logger.Info("tls certificates loaded", "domains", subject_10.OkVal)
+		} else {
+			e := subject_10.ErrVal
+			s.logger.Error("server error", "error", e.Error())
+			return e
+		}
+	}
+	subject_11 := s.config_file
+	if subject_11.Tag == lisette.OptionSome {
+		cf := subject_11.SomeVal
+		ret_12 := cf.load()
+		var result_13 lisette.Result[struct{}, error]
+		if ret_12 != nil {
+			result_13 = lisette.MakeResultErr[struct{}, error](ret_12)
+		} else {
+			result_13 = lisette.MakeResultOk[struct{}, error](struct{}{})
+		}
+		subject_14 := result_13
+		if subject_14.Tag == lisette.ResultErr {
+			e := subject_14.ErrVal
+			s.logger.Error("config file load failed", "path", cf.path, "error", e.Error())
+			return e
+		}
+	}
+	ret_15, err_16 := s.listen()
+	var result_17 lisette.Result[net.Listener, error]
+	if err_16 != nil {
+		result_17 = lisette.MakeResultErr[net.Listener, error](err_16)
 	} else {
-		result_3 = lisette.MakeResultOk[struct{}, error](struct{}{})
+		result_17 = lisette.MakeResultOk[net.Listener, error](ret_15)
+	}
+	subject_18 := result_17
+	if subject_18.Tag != lisette.ResultOk {
+		e := subject_18.ErrVal
+		s.logger.Error("server error", "error", e.Error())
+		return e
+	}
+	ln := subject_18.OkVal
+	s.warn_if_unreachable(ln.Addr())
+	for _, hook := range s.post_bind_hooks {
+		ret_19 := hook()
+		var result_20 lisette.Result[struct{}, error]
+		if ret_19 != nil {
+			result_20 = lisette.MakeResultErr[struct{}, error](ret_19)
+		} else {
+			result_20 = lisette.MakeResultOk[struct{}, error](struct{}{})
+		}
+		subject_21 := result_20
+		if subject_21.Tag == lisette.ResultErr {
+			e := subject_21.ErrVal
+			ln.Close()
+			s.logger.Error("post-bind hook failed", "error", e.Error())
+			return e
+		}
+	}
+	subject_22 := s.redirect_srv
+	if subject_22.Tag == lisette.OptionSome {
+		rs := subject_22.SomeVal
+		ret_23, err_24 := net.Listen("tcp", rs.Addr)
+		var result_25 lisette.Result[net.Listener, error]
+		if err_24 != nil {
+			result_25 = lisette.MakeResultErr[net.Listener, error](err_24)
+		} else {
+			result_25 = lisette.MakeResultOk[net.Listener, error](ret_23)
+		}
+		subject_26 := result_25
+		if subject_26.Tag == lisette.ResultOk {
+			rln := subject_26.OkVal
+			go func() {
+				s.serve_redirect(rs, rln)
+			}()
+		} else {
+			e := subject_26.ErrVal
+			ln.Close()
+			s.logger.Error("redirect server error", "error", e.Error())
+			return e
+		}
+	}
+	if s.start_time.Load() == nil {
+		now := s.clock.Now()
+		addr := ln.Addr()
+		s.bound_addr.Store(&addr)
+		s.start_time.Store(&now)
+		close(s.listening)
+	}
+	if len(s.startup_hooks) > 0 || len(s.warmups) > 0 {
+		go func() {
+			s.start_up()
+		}()
 	}
-	subject_1 := result_3
-	if subject_1.Tag == lisette.ResultOk {
+	subject_27 := s.config_file
+	if subject_27.Tag == lisette.OptionSome {
+		cf := subject_27.SomeVal
+		go func() {
+			cf.watch(s.ctx, s.logger)
+		}()
+	}
+	subject_28 := s.conns
+	if subject_28.Tag == lisette.OptionSome {
+		t := subject_28.SomeVal
+		go func() {
+			t.log_every(s.ctx, s.logger, CONN_METRICS_INTERVAL)
+		}()
+	}
+	var ret_29 error
+	if s.srv.TLSConfig != nil {
+		ret_29 = s.srv.ServeTLS(ln, "", "")
+	} else {
+		ret_29 = s.srv.Serve(ln)
+	}
+	subject_30 := s.redirect_srv
+	if subject_30.Tag == lisette.OptionSome {
+		subject_30.SomeVal.Close()
+	}
+	var result_31 lisette.Result[struct{}, error]
+	if ret_29 != nil {
+		result_31 = lisette.MakeResultErr[struct{}, error](ret_29)
+	} else {
+		result_31 = lisette.MakeResultOk[struct{}, error](struct{}{})
+	}
+	subject_32 := result_31
+	if subject_32.Tag == lisette.ResultOk {
 		return nil
 	}
-	e := subject_1.ErrVal
+	e := subject_32.ErrVal
 	if errors.Is(e, http.ErrServerClosed) {
+		raw_33 := s.failed.Load()
+		option_34 := lisette.OptionFromNilable[*error](raw_33, raw_33 == nil)
+		if option_34.Tag == lisette.OptionSome {
+			return *option_34.SomeVal
+		}
 		return nil
 	}
-	callee_4 := s.logger.Error
-	callee_4("server error", "error", e.Error())
+	callee_38 := s.logger.Error
+	callee_38("server error", "error", e.Error())
 	return e
 }
 
+func (s *Server) serve_redirect(rs *http.Server, ln net.Listener) {
+	ret_1 := rs.Serve(ln)
+	var result_2 lisette.Result[struct{}, error]
+	if ret_1 != nil {
+		result_2 = lisette.MakeResultErr[struct{}, error](ret_1)
+	} else {
+		result_2 = lisette.MakeResultOk[struct{}, error](struct{}{})
+	}
+	check_3 := result_2
+	if check_3.Tag != lisette.ResultErr {
+		return
+	}
+	e := check_3.ErrVal
+	if errors.Is(e, http.ErrServerClosed) {
+		return
+	}
+	s.logger.Error("redirect server error", "error", e.Error())
+	s.failed.Store(&e)
+	s.srv.Close()
+}
+
+func (s *Server) listen_address() string {
+	subject_1 := s.listener
+	if subject_1.Tag == lisette.OptionSome {
+		return subject_1.SomeVal.Addr().String()
+	}
+	subject_2 := s.unix_socket
+	if subject_2.Tag == lisette.OptionSome {
+		return fmt.Sprintf("unix://%s", subject_2.SomeVal)
+	}
+	return s.srv.Addr
+}
+
+func (s *Server) bind() (net.Listener, error) {
+	subject_1 := s.listener
+	if subject_1.Tag == lisette.OptionSome {
+		return subject_1.SomeVal, nil
+	}
+	subject_2 := s.unix_socket
+	if subject_2.Tag == lisette.OptionSome {
+		return listen_unix(subject_2.SomeVal, s.logger)
+	}
+	addr := s.srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	lc := s.listen_config
+	if s.reuse_port {
+		raw_3 := lc.Control
+		option_4 := lisette.OptionFromNilable[ControlFunc](raw_3, raw_3 == nil)
+		lc.Control = reuse_port_control(option_4)
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	ret_1, err_2 := s.bind()
+	var result_3 lisette.Result[net.Listener, error]
+	if err_2 != nil {
+		result_3 = lisette.MakeResultErr[net.Listener, error](err_2)
+	} else {
+		result_3 = lisette.MakeResultOk[net.Listener, error](ret_1)
+	}
+	check_4 := result_3
+	if check_4.Tag != lisette.ResultOk {
+		return nil, check_4.ErrVal
+	}
+	var ln net.Listener = ServerListener{ln: check_4.OkVal, timeouts: s.timeouts, idle_jitter: s.idle_jitter, total_timeout: s.total_request_timeout}
+	subject_5 := s.accept_error_handler
+	if subject_5.Tag == lisette.OptionSome {
+		return AcceptObserver{ln: ln, on_error: subject_5.SomeVal}, nil
+	}
+	return ln, nil
+}
+
+func (s *Server) warn_if_unreachable(addr net.Addr) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return
+	}
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return
+	}
+	if tcp.IP.IsLoopback() {
+		s.logger.Warn("server bound to loopback inside Kubernetes; it will not accept external traffic", "addr", addr.String())
+	}
+}
+
+func (s *Server) start_up() {
+	defer s.starting.Store(false)
+	for _, hook := range s.startup_hooks {
+		ret_2 := hook(s.ctx)
+		var result_3 lisette.Result[struct{}, error]
+		if ret_2 != nil {
+			result_3 = lisette.MakeResultErr[struct{}, error](ret_2)
+		} else {
+			result_3 = lisette.MakeResultOk[struct{}, error](struct{}{})
+		}
+		subject_1 := result_3
+		if subject_1.Tag == lisette.ResultErr {
+			e := subject_1.ErrVal
+			s.logger.Error("startup hook failed", "error", e.Error())
+			s.failed.Store(&e)
+			_ = s.srv.Close()
+			return
+		}
+	}
+	for _, w := range s.warmups {
+		ret_5 := w(s.ctx)
+		var result_6 lisette.Result[struct{}, error]
+		if ret_5 != nil {
+			result_6 = lisette.MakeResultErr[struct{}, error](ret_5)
+		} else {
+			result_6 = lisette.MakeResultOk[struct{}, error](struct{}{})
+		}
+		subject_4 := result_6
+		if subject_4.Tag == lisette.ResultErr {
+			e := subject_4.ErrVal
+			if s.warmup_required {
+				s.logger.Error("warmup failed, aborting startup", "error", e.Error())
+				s.failed.Store(&e)
+				_ = s.srv.Close()
+				return
+			}
+			s.logger.Warn("warmup failed", "error", e.Error())
+		}
+	}
+	raw_8 := s.ctx.Err()
+	option_9 := lisette.OptionFromNilable[error](raw_8, lisette.IsNilInterface(raw_8))
+	subject_7 := option_9
+	if subject_7.Tag == lisette.OptionSome {
+		return
+	}
+	s.ready.Store(true)
+}
+
 func (s *Server) Run() error {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-	return s.run_with_context(ctx)
+	if s.embedded {
+		s.logger.Warn("embedded mode: run installs no signal handler, serving until shutdown")
+		if err := s.Start(); err != nil {
+			return err
+		}
+		return s.wait_shut_down()
+	}
+	return run_on_signals([]*Server{s}, s.shutdown_signals, func(ctx context.Context) error {
+		return s.run_until(ctx, "signal")
+	})
+}
+
+func (s *Server) force_close() {
+	subject_1 := s.redirect_srv
+	if subject_1.Tag == lisette.OptionSome {
+		subject_1.SomeVal.Close()
+	}
+	s.srv.Close()
+}
+
+func (s *Server) abandon(ctx context.Context) {
+	s.cancel()
+	s.srv.Shutdown(ctx)
+}
+
+func (s *Server) RunContext(ctx context.Context) error {
+	return s.run_until(ctx, "context")
 }
 
-func (s *Server) run_with_context(ctx context.Context) error {
+func (s *Server) run_until(ctx context.Context, reason string) error {
 	start_err := make(chan error, 1)
 	go func() {
 		ret_2 := s.Start()
@@ -123,7 +982,9 @@ func (s *Server) run_with_context(ctx context.Context) error {
 			result_3 = lisette.MakeResultOk[struct{}, error](struct{}{})
 		}
 		subject_1 := result_3
-		if subject_1.Tag == lisette.ResultErr {
+		if subject_1.Tag == lisette.ResultOk {
+			close(start_err)
+		} else {
 			_ = lisette.ChannelSend(start_err, subject_1.ErrVal)
 		}
 	}()
@@ -133,47 +994,342 @@ func (s *Server) run_with_context(ctx context.Context) error {
 		if ok {
 			return e
 		}
+		return s.wait_shut_down()
 	case _, ok := <-done:
 		_ = ok
 	}
 	s.ready.Store(false)
-	return s.Shutdown(context.Background())
+	return s.shutdown_for(context.Background(), reason)
+}
+
+func (s *Server) Close() error {
+	if s.start_time.Load() == nil {
+		return nil
+	}
+	s.close_once.Do(func() {
+		if !s.shutdown_once.Load() {
+			_ = s.Shutdown(context.Background())
+		}
+	})
+	return s.wait_shut_down()
+}
+
+func (s *Server) wait_shut_down() error {
+	<-s.shut_down
+	raw_2 := s.shutdown_err.Load()
+	option_3 := lisette.OptionFromNilable[*error](raw_2, raw_2 == nil)
+	subject_1 := option_3
+	if subject_1.Tag == lisette.OptionSome {
+		return *subject_1.SomeVal
+	}
+	return nil
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	return s.shutdown_for(ctx, "shutdown")
+}
+
+func (s Server) ShutdownRecord() (ShutdownRecord, bool) {
+	raw_2 := s.record.Load()
+	option_3 := lisette.OptionFromNilable[*ShutdownRecord](raw_2, raw_2 == nil)
+	subject_1 := option_3
+	if subject_1.Tag == lisette.OptionSome {
+		return *subject_1.SomeVal, true
+	}
+	return ShutdownRecord{}, false
+}
+
+func (s *Server) shutdown_for(ctx context.Context, reason string) error {
+	first := !s.shutdown_once.Swap(true)
+	began := s.clock.Now()
+	started_at := s.StartedAt()
+	rec := &ShutdownRecord{Reason: reason, StartedAt: started_at}
+	if !started_at.IsZero() {
+		rec.Uptime = began.Sub(started_at)
+	}
+	res := s.drain_and_run_hooks(ctx, rec, first)
+	raw_2 := s.shutdown_signal.Load()
+	option_3 := lisette.OptionFromNilable[*string](raw_2, raw_2 == nil)
+	subject_1 := option_3
+	if subject_1.Tag == lisette.OptionSome {
+		rec.Signal = *subject_1.SomeVal
+	}
+	rec.Requests = s.stats.requests.Load()
+	rec.Duration = s.clock.Now().Sub(began)
+	if res != nil {
+		e := res
+		rec.Error = e.Error()
+	}
+	s.record.Store(rec)
+	log_shutdown_record(s.logger, rec)
+	if first {
+		if res != nil {
+			e := res
+			s.shutdown_err.Store(&e)
+		}
+		close(s.shut_down)
+	}
+	return res
+}
+
+func (s *Server) drain_and_run_hooks(ctx context.Context, rec *ShutdownRecord, first bool) error {
 	s.logger.Info("server shutting down")
+	s.ready.Store(false)
+	defer s.report(ShutdownPhaseDone)
 	timeout_ctx, cancel := context.WithTimeout(ctx, s.shutdown_timeout)
 	defer cancel()
-	ret_1 := s.srv.Shutdown(timeout_ctx)
-	var result_2 lisette.Result[struct{}, error]
-	if ret_1 != nil {
-		result_2 = lisette.MakeResultErr[struct{}, error](ret_1)
+	drain_ctx, cancel_drain := context.WithTimeout(timeout_ctx, s.drain_timeout())
+	defer cancel_drain()
+	if s.pre_shutdown_delay > 0 {
+		s.logger.Info("waiting for load balancers before draining", "delay", s.pre_shutdown_delay)
+		timer := time.NewTimer(s.pre_shutdown_delay)
+		defer timer.Stop()
+		expired := drain_ctx.Done()
+		select {
+		case _, ok := <-timer.C:
+			_ = ok
+		case _, ok := <-expired:
+			_ = ok
+		}
+	}
+	s.cancel()
+	closed := make(chan struct{}, 1)
+	reporting := first && s.shutdown_progress.Tag == lisette.OptionSome
+	if reporting {
+		once := &sync.Once{}
+		s.srv.RegisterOnShutdown(func() {
+			once.Do(func() {
+				s.report(ShutdownPhaseStarted)
+				_ = lisette.ChannelSend(closed, struct{}{})
+			})
+		})
+	}
+	if s.cancel_threshold > 0 {
+		go func() {
+			s.cancel_stuck(drain_ctx)
+		}()
+	}
+	open := s.stats.conns.Load()
+	subject_1 := s.redirect_srv
+	if subject_1.Tag == lisette.OptionSome {
+		subject_1.SomeVal.Shutdown(drain_ctx)
+	}
+	ret_2 := s.srv.Shutdown(drain_ctx)
+	var result_3 lisette.Result[struct{}, error]
+	if ret_2 != nil {
+		result_3 = lisette.MakeResultErr[struct{}, error](ret_2)
 	} else {
-		result_2 = lisette.MakeResultOk[struct{}, error](struct{}{})
+		result_3 = lisette.MakeResultOk[struct{}, error](struct{}{})
 	}
-	check_3 := result_2
-	if check_3.Tag != lisette.ResultOk {
-		return check_3.ErrVal
+	drained := result_3
+	cancel_drain()
+	if reporting {
+		<-closed
+	}
+	left := int64(0)
+	if drained.Tag == lisette.ResultErr {
+		left = s.stats.conns.Load()
+	}
+	rec.ConnectionsDrained = int(max(open-left, 0))
+	rec.ConnectionsForceClosed = int(left)
+	if drained.Tag == lisette.ResultErr {
+		s.log_in_flight()
+		s.force_close()
+	}
+	s.stop()
+	s.report(ShutdownPhaseDrained)
+	if len(s.shutdown_hooks) > 0 {
+		s.check_hook_budget(timeout_ctx)
+	}
+	runs := s.run_hooks(timeout_ctx)
+	failed := ([]HookError)(nil)
+	for i := 0; i < len(runs); i++ {
+		h := s.shutdown_hooks[i]
+		result := HookResult{Name: h.name, Duration: runs[i].duration, Error: ""}
+		subject_4 := runs[i].err
+		if subject_4.Tag == lisette.OptionSome {
+			e := subject_4.SomeVal
+			result.Error = e.Error()
+			failed = append(failed, HookError{HookIndex: i, Name: h.name, Err: e})
+		}
+		rec.Hooks = append(rec.Hooks, result)
 	}
 	errs := ([]error)(nil)
-	for _, hook := range s.shutdown_hooks {
-		ret_5 := hook(timeout_ctx)
-		var result_6 lisette.Result[struct{}, error]
-		if ret_5 != nil {
-			result_6 = lisette.MakeResultErr[struct{}, error](ret_5)
+	subject_5 := drained
+	if subject_5.Tag == lisette.ResultErr {
+		errs = append(errs, subject_5.ErrVal)
+	}
+	if len(failed) > 0 {
+		errs = append(errs, &ShutdownError{Hooks: failed})
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	raw_7 := errors.Join(errs...)
+	option_8 := lisette.OptionFromNilable[error](raw_7, lisette.IsNilInterface(raw_7))
+	subject_6 := option_8
+	if subject_6.Tag == lisette.OptionSome {
+		return subject_6.SomeVal
+	}
+	return nil
+}
+
+func (s *Server) run_hooks(ctx context.Context) []HookRun {
+	runs := ([]HookRun)(nil)
+	for range s.shutdown_hooks {
+		runs = append(runs, HookRun{duration: 0, err: lisette.MakeOptionNone[error]()})
+	}
+	run := func(i int) {
+		h := s.shutdown_hooks[i]
+		began := s.clock.Now()
+		ret_1 := s.run_hook(h, ctx)
+		var result_2 lisette.Result[struct{}, error]
+		if ret_1 != nil {
+			result_2 = lisette.MakeResultErr[struct{}, error](ret_1)
 		} else {
-			result_6 = lisette.MakeResultOk[struct{}, error](struct{}{})
+			result_2 = lisette.MakeResultOk[struct{}, error](struct{}{})
 		}
-		subject_4 := result_6
-		if subject_4.Tag == lisette.ResultErr {
-			errs = append(errs, subject_4.ErrVal)
+		subject_3 := result_2
+		var err lisette.Option[error]
+		if subject_3.Tag == lisette.ResultOk {
+			err = lisette.MakeOptionNone[error]()
+		} else {
+			err = lisette.MakeOptionSome(subject_3.ErrVal)
 		}
+		runs[i] = HookRun{duration: s.clock.Now().Sub(began), err: err}
 	}
-	raw_8 := errors.Join(errs...)
-	option_9 := lisette.OptionFromNilable[error](raw_8, lisette.IsNilInterface(raw_8))
-	subject_7 := option_9
-	if subject_7.Tag == lisette.OptionSome {
-		return subject_7.SomeVal
+	if s.hook_concurrency <= 1 {
+		for i := 0; i < len(runs); i++ {
+			run(i)
+		}
+		return runs
 	}
-	return nil
+	slots := make(chan struct{}, s.hook_concurrency)
+	running := &sync.WaitGroup{}
+	for i := 0; i < len(runs); i++ {
+		_ = lisette.ChannelSend(slots, struct{}{})
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			run(i)
+			<-slots
+		}()
+	}
+	running.Wait()
+	return runs
+}
+
+func (s *Server) drain_timeout() time.Duration {
+	if len(s.shutdown_hooks) == 0 {
+		return s.shutdown_timeout
+	}
+	reserve := s.hook_budget
+	if reserve > s.shutdown_timeout/2 {
+		reserve = s.shutdown_timeout / 2
+	}
+	return s.shutdown_timeout - reserve
+}
+
+func (s *Server) check_hook_budget(ctx context.Context) {
+	ret_1, ok_2 := ctx.Deadline()
+	var option_3 lisette.Option[time.Time]
+	if ok_2 {
+		option_3 = lisette.MakeOptionSome[time.Time](ret_1)
+	} else {
+		option_3 = lisette.MakeOptionNone[time.Time]()
+	}
+	subject_4 := option_3
+	if subject_4.Tag != lisette.OptionSome {
+		return
+	}
+	deadline := subject_4.SomeVal
+	remaining := time.Until(deadline)
+	if remaining < s.hook_budget {
+		s.logger.Warn("shutdown hook budget low", "remaining", remaining, "budget", s.hook_budget)
+	}
+}
+
+func (s *Server) log_in_flight() {
+	for _, snap := range s.InFlight() {
+		s.logger.Warn("request still in flight", "method", snap.Method, "path", snap.Path, "remote_addr", snap.RemoteAddr, "age", snap.Age)
+	}
+}
+
+func (s *Server) cancel_stuck(ctx context.Context) {
+	subject_1 := s.tracker
+	if subject_1.Tag != lisette.OptionSome {
+		return
+	}
+	tracker := subject_1.SomeVal
+	ticker := time.NewTicker(SHUTDOWN_CANCEL_POLL)
+	defer ticker.Stop()
+	done := ctx.Done()
+	for {
+		for _, snap := range tracker.cancel_older_than(s.cancel_threshold) {
+			s.logger.Warn("cancelling long-running request", "method", snap.Method, "path", snap.Path, "remote_addr", snap.RemoteAddr, "age", snap.Age)
+		}
+		select {
+		case _, ok := <-done:
+			_ = ok
+			return
+		case _, ok := <-ticker.C:
+			_ = ok
+		}
+	}
+}
+
+func (s *Server) report(phase ShutdownPhase) {
+	subject_1 := s.shutdown_progress
+	if subject_1.Tag == lisette.OptionSome {
+		_ = lisette.ChannelSend(subject_1.SomeVal, phase)
+	}
+}
+
+func (s *Server) run_hook(h NamedHook, shutdown_ctx context.Context) error {
+	name := h.name
+	ctx := shutdown_ctx
+	if h.timeout > 0 {
+		hook_ctx, cancel := context.WithTimeout(shutdown_ctx, h.timeout)
+		defer cancel()
+		ctx = hook_ctx
+	}
+	slow := lisette.MakeOptionNone[*time.Timer]()
+	ret_1, ok_2 := ctx.Deadline()
+	var option_3 lisette.Option[time.Time]
+	if ok_2 {
+		option_3 = lisette.MakeOptionSome[time.Time](ret_1)
+	} else {
+		option_3 = lisette.MakeOptionNone[time.Time]()
+	}
+	subject_4 := option_3
+	if subject_4.Tag == lisette.OptionSome {
+		deadline := subject_4.SomeVal
+		if s.hook_slow_threshold > 0 {
+			budget := time.Until(deadline)
+			after := time.Duration(float64(budget) * s.hook_slow_threshold)
+			slow = lisette.MakeOptionSome(time.AfterFunc(after, func() {
+				s.logger.Warn("shutdown hook is slow", "hook", name, "elapsed", after, "budget", budget)
+			}))
+		}
+	}
+	s.logger.Debug("shutdown hook started", "hook", name)
+	start := s.clock.Now()
+	var res error
+	if h.timeout > 0 {
+		res = call_bounded(h.hook, ctx)
+	} else {
+		res = h.hook(ctx)
+	}
+	subject_5 := slow
+	if subject_5.Tag == lisette.OptionSome {
+		_ = subject_5.SomeVal.Stop()
+	}
+	took := s.clock.Now().Sub(start)
+	if res != nil {
+		e := res
+		s.logger.Error("shutdown hook failed", "hook", name, "duration", took, "error", e.Error())
+	} else {
+		s.logger.Debug("shutdown hook finished", "hook", name, "duration", took)
+	}
+	return res
 }
@@ -0,0 +1,53 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestShutdownErrorListsFailedHooks(t *testing.T) {
+	var logs logSink
+	errCache := errors.New("cache flush failed")
+	errQueue := errors.New("queue close failed")
+	s, _ := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithNamedShutdownHook("cache", func(context.Context) error { return errCache }),
+		httpserver.WithNamedShutdownHook("db", func(context.Context) error { return nil }),
+		httpserver.WithNamedShutdownHook("queue", func(context.Context) error { return errQueue }),
+	)
+
+	err := s.Shutdown(context.Background())
+	var se *httpserver.ShutdownError
+	if !errors.As(err, &se) {
+		t.Fatalf("Shutdown = %v (%T), want a *ShutdownError", err, err)
+	}
+	want := []httpserver.HookError{
+		{HookIndex: 0, Name: "cache", Err: errCache},
+		{HookIndex: 2, Name: "queue", Err: errQueue},
+	}
+	if len(se.Hooks) != len(want) {
+		t.Fatalf("ShutdownError lists %d hooks, want %d", len(se.Hooks), len(want))
+	}
+	for i, w := range want {
+		if se.Hooks[i] != w {
+			t.Errorf("Hooks[%d] = %+v, want %+v", i, se.Hooks[i], w)
+		}
+	}
+	for _, e := range []error{errCache, errQueue} {
+		if !errors.Is(err, e) {
+			t.Errorf("errors.Is(err, %q) = false, want true", e)
+		}
+	}
+	if len(se.Unwrap()) != 2 {
+		t.Errorf("Unwrap returned %d errors, want 2", len(se.Unwrap()))
+	}
+	for _, sub := range []string{"shutdown hook cache: cache flush failed", "shutdown hook queue: queue close failed"} {
+		if !strings.Contains(err.Error(), sub) {
+			t.Errorf("error %q lacks %q", err.Error(), sub)
+		}
+	}
+}
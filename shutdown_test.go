@@ -0,0 +1,320 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestShutdownDrainsInFlightAndRefusesNew(t *testing.T) {
+	var logs logSink
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	progress := make(chan httpserver.ShutdownPhase, 16)
+	s, addr := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithShutdownProgress(progress),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release
+			io.WriteString(w, "done")
+		})),
+	)
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	inflight := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			inflight <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		inflight <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+	<-entered
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+	if phase := nextPhase(t, progress); phase != httpserver.ShutdownPhaseStarted {
+		t.Fatalf("first phase = %v, want Started", phase)
+	}
+
+	fresh := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: 2 * time.Second}
+	if resp, err := fresh.Get("http://" + addr + "/new"); err == nil {
+		resp.Body.Close()
+		t.Fatalf("new request during shutdown got %d, want the connection refused", resp.StatusCode)
+	}
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned %v before the in-flight request completed", err)
+	default:
+	}
+	close(release)
+
+	res := <-inflight
+	if res.err != nil || res.status != http.StatusOK || res.body != "done" {
+		t.Fatalf("in-flight request: status %d, body %q, err %v; want 200 done", res.status, res.body, res.err)
+	}
+	for _, want := range []httpserver.ShutdownPhase{httpserver.ShutdownPhaseDrained, httpserver.ShutdownPhaseDone} {
+		if phase := nextPhase(t, progress); phase != want {
+			t.Fatalf("phase = %v, want %v", phase, want)
+		}
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
+
+func TestShutdownResponseOnKeepAliveConnection(t *testing.T) {
+	var logs logSink
+	hold := make(chan struct{})
+	held := make(chan struct{})
+	progress := make(chan httpserver.ShutdownPhase, 16)
+	s, addr := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithShutdownProgress(progress),
+		httpserver.WithShutdownResponse(http.StatusServiceUnavailable, "shutting down"),
+		// Holds the second request between being read and being dispatched,
+		// so that it reaches the handler after shutdown has begun.
+		httpserver.WithMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/held" {
+					close(held)
+					<-hold
+				}
+				next.ServeHTTP(w, r)
+			})
+		}),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok")
+		})),
+	)
+
+	var reused []bool
+	trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) {
+		reused = append(reused, info.Reused)
+	}}
+	client := &http.Client{Transport: &http.Transport{}}
+	get := func(path string) *http.Response {
+		req, err := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, "http://"+addr+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		return resp
+	}
+
+	first := get("/first")
+	io.Copy(io.Discard, first.Body)
+	first.Body.Close()
+	if first.StatusCode != http.StatusOK || first.Close {
+		t.Fatalf("before shutdown: status %d, close %v; want 200 on a kept-alive connection", first.StatusCode, first.Close)
+	}
+
+	second := make(chan *http.Response, 1)
+	go func() { second <- get("/held") }()
+	<-held
+	go s.Shutdown(context.Background())
+	if phase := nextPhase(t, progress); phase != httpserver.ShutdownPhaseStarted {
+		t.Fatalf("first phase = %v, want Started", phase)
+	}
+	close(hold)
+
+	resp := <-second
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if len(reused) != 2 || !reused[1] {
+		t.Fatalf("second request reused a connection: %v, want it on the first one's", reused)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || string(body) != "shutting down" {
+		t.Errorf("after shutdown: status %d, body %q; want 503 shutting down", resp.StatusCode, body)
+	}
+	if !resp.Close {
+		t.Error("after shutdown: response does not close the connection")
+	}
+}
+
+func TestShutdownForceClosesUndrainedConnections(t *testing.T) {
+	var logs logSink
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	s, addr := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release
+		})),
+	)
+	client := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/stuck")
+		if err == nil {
+			resp.Body.Close()
+		}
+		client <- err
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want the drain's context.DeadlineExceeded", err)
+	}
+	select {
+	case err := <-client:
+		if err == nil {
+			t.Error("stuck request got a response, want its connection closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection still open after the drain was cut off")
+	}
+	rec, _ := s.ShutdownRecord()
+	if rec.ConnectionsForceClosed != 1 {
+		t.Errorf("ConnectionsForceClosed = %d, want 1", rec.ConnectionsForceClosed)
+	}
+}
+
+func TestWaitReadyAfterShutdown(t *testing.T) {
+	var logs logSink
+	s, _ := startServer(t, httpserver.WithLogger(logs.logger()))
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Listening and stopped are both closed now; ready must win every time.
+	for i := 0; i < 100; i++ {
+		if err := s.WaitReady(context.Background()); err != nil {
+			t.Fatalf("WaitReady after shutdown = %v, want nil", err)
+		}
+	}
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	var logs logSink
+	var runs atomic.Int32
+	failure := errors.New("flush failed")
+	s, _ := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithShutdownHook(func(context.Context) error {
+			runs.Add(1)
+			return failure
+		}),
+	)
+
+	if err := s.Shutdown(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("Shutdown = %v, want the hook's error", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.Close(); !errors.Is(err, failure) {
+			t.Errorf("Close #%d = %v, want the first shutdown's error", i+1, err)
+		}
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("hook ran %d times, want 1", n)
+	}
+}
+
+func TestRepeatedShutdownReportsStartedOnce(t *testing.T) {
+	var logs logSink
+	progress := make(chan httpserver.ShutdownPhase, 16)
+	s, _ := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithShutdownProgress(progress),
+	)
+	for i := 0; i < 3; i++ {
+		if err := s.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Give net/http's on-shutdown goroutines time to report, if they were to.
+	time.Sleep(50 * time.Millisecond)
+	started := 0
+	for len(progress) > 0 {
+		if <-progress == httpserver.ShutdownPhaseStarted {
+			started++
+		}
+	}
+	if started != 1 {
+		t.Errorf("Started reported %d times, want 1", started)
+	}
+}
+
+func TestRunContextWaitsForShutdownElsewhere(t *testing.T) {
+	checkReturnsAfterHooks(t, func(s *httpserver.Server) error {
+		return s.RunContext(context.Background())
+	})
+}
+
+func TestEmbeddedRunWaitsForShutdown(t *testing.T) {
+	checkReturnsAfterHooks(t, (*httpserver.Server).Run, httpserver.WithEmbeddedMode())
+}
+
+// checkReturnsAfterHooks serves a server built from opts with run, shuts it
+// down from elsewhere and checks that run returns only once the server's slow
+// shutdown hook has finished.
+func checkReturnsAfterHooks(t *testing.T, run func(*httpserver.Server) error, opts ...httpserver.ServerOption) {
+	t.Helper()
+	var logs logSink
+	var hooked atomic.Bool
+	s := httpserver.New(append([]httpserver.ServerOption{
+		httpserver.WithListener(listenLoopback(t)),
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithShutdownHook(func(context.Context) error {
+			time.Sleep(300 * time.Millisecond)
+			hooked.Store(true)
+			return nil
+		}),
+	}, opts...))
+	errc := make(chan error, 1)
+	go func() { errc <- run(s) }()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("run = %v, want nil", err)
+		}
+		if !hooked.Load() {
+			t.Error("run returned before the shutdown hook finished")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not return after shutdown")
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown = %v", err)
+	}
+}
+
+func nextPhase(t *testing.T, progress chan httpserver.ShutdownPhase) httpserver.ShutdownPhase {
+	t.Helper()
+	select {
+	case phase := <-progress:
+		return phase
+	case <-time.After(5 * time.Second):
+		t.Fatal("no shutdown progress")
+		return 0
+	}
+}
@@ -0,0 +1,79 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestStartingResponseUntilStartupHooksComplete(t *testing.T) {
+	release := make(chan struct{})
+	_, addr := startServer(t,
+		httpserver.WithStartupHook(func(ctx context.Context) error {
+			<-release
+			return nil
+		}),
+		httpserver.WithStartingResponse(http.StatusTooEarly, "warming up"),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok")
+		})),
+	)
+
+	if status, body := fetch(t, "http://"+addr+"/"); status != http.StatusTooEarly || body != "warming up" {
+		t.Errorf("during the startup hook got %d %q, want %d %q", status, body, http.StatusTooEarly, "warming up")
+	}
+	if status, _ := fetch(t, "http://"+addr+"/livez"); status != http.StatusOK {
+		t.Errorf("/livez during the startup hook = %d, want 200", status)
+	}
+	if status, _ := fetch(t, "http://"+addr+"/readyz"); status != http.StatusServiceUnavailable {
+		t.Errorf("/readyz during the startup hook = %d, want 503", status)
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, body := fetch(t, "http://"+addr+"/")
+		if status == http.StatusOK && body == "ok" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("after the startup hook got %d %q, want 200 %q", status, body, "ok")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status, _ := fetch(t, "http://"+addr+"/readyz"); status != http.StatusOK {
+		t.Errorf("/readyz after the startup hook = %d, want 200", status)
+	}
+}
+
+func TestStartupHookErrorStopsServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hookErr := errors.New("migration failed")
+	s := httpserver.New([]httpserver.ServerOption{
+		httpserver.WithListener(ln),
+		httpserver.WithStartupHook(func(ctx context.Context) error { return hookErr }),
+	})
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start() }()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, hookErr) {
+			t.Fatalf("Start = %v, want %v", err, hookErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after the startup hook failed")
+	}
+	if conn, err := net.Dial("tcp", ln.Addr().String()); err == nil {
+		conn.Close()
+		t.Error("listener still accepts connections after the startup hook failed")
+	}
+}
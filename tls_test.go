@@ -0,0 +1,197 @@
+package httpserver_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/banan-tech/httpserver"
+)
+
+// testCA issues certificates for the TLS tests, entirely in memory.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// issue returns a certificate signed by ca for names, usable by a server, or
+// by a client when names is empty.
+func (ca *testCA) issue(t *testing.T, names ...string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := x509.ExtKeyUsageServerAuth
+	if len(names) == 0 {
+		usage = x509.ExtKeyUsageClientAuth
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "test"},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// writeKeyPair writes cert and its key as PEM files and returns their paths.
+func writeKeyPair(t *testing.T, cert tls.Certificate) (string, string) {
+	t.Helper()
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// servedName dials addr with SNI name and returns the first DNS name of the
+// certificate the server presented, whichever name it is for.
+func servedName(t *testing.T, addr string, name string) string {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: name, InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("handshake for %s: %v", name, err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].DNSNames[0]
+}
+
+func TestCertificatesSelectedBySNI(t *testing.T) {
+	var logs logSink
+	ca := newTestCA(t)
+	_, addr := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithCertificates(ca.issue(t, "a.example"), ca.issue(t, "b.example")),
+		httpserver.WithHandler(http.NotFoundHandler()),
+	)
+
+	for _, name := range []string{"a.example", "b.example"} {
+		if got := servedName(t, addr, name); got != name {
+			t.Errorf("SNI %s: served certificate for %s", name, got)
+		}
+	}
+}
+
+func TestTLSConfigWinsOverTLSFiles(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := writeKeyPair(t, ca.issue(t, "files.example"))
+	config := &tls.Config{Certificates: []tls.Certificate{ca.issue(t, "config.example")}}
+	orders := map[string][]httpserver.ServerOption{
+		"WithTLS first":       {httpserver.WithTLS(certFile, keyFile), httpserver.WithTLSConfig(config)},
+		"WithTLSConfig first": {httpserver.WithTLSConfig(config), httpserver.WithTLS(certFile, keyFile)},
+	}
+	for name, tlsOpts := range orders {
+		t.Run(name, func(t *testing.T) {
+			var logs logSink
+			opts := append([]httpserver.ServerOption{
+				httpserver.WithLogger(logs.logger()),
+				httpserver.WithHandler(http.NotFoundHandler()),
+			}, tlsOpts...)
+			_, addr := startServer(t, opts...)
+			if got := servedName(t, addr, "files.example"); got != "config.example" {
+				t.Errorf("served certificate for %s, want the WithTLSConfig one", got)
+			}
+		})
+	}
+}
+
+func TestClientCertificatesRequired(t *testing.T) {
+	var logs logSink
+	ca := newTestCA(t)
+	_, addr := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithCertificates(ca.issue(t, "api.example")),
+		httpserver.WithClientCAs(ca.pool, tls.RequireAndVerifyClientCert),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+	)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			ServerName:   "api.example",
+			RootCAs:      ca.pool,
+			Certificates: certs,
+		}}}
+	}
+
+	resp, err := client(ca.issue(t)).Get("https://" + addr + "/")
+	if err != nil {
+		t.Fatalf("with a client certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("with a client certificate: status = %d, want 200", resp.StatusCode)
+	}
+
+	if resp, err := client().Get("https://" + addr + "/"); err == nil {
+		resp.Body.Close()
+		t.Fatal("without a client certificate: request succeeded, want a handshake failure")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !logs.contains("TLS handshake error") {
+		if time.Now().After(deadline) {
+			t.Fatal("handshake failure not logged through the error logger")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,63 @@
+package httpserver_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestTotalRequestTimeoutCutsOffSlowClient(t *testing.T) {
+	var logs logSink
+	read := make(chan error, 1)
+	_, addr := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithTotalRequestTimeout(300*time.Millisecond),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.ReadAll(r.Body)
+			read <- err
+		})),
+	)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	start := time.Now()
+	if _, err := io.WriteString(conn, "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 100\r\n\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	// One byte every 50ms: each read is quick, the upload as a whole takes 5s.
+	go func() {
+		for i := 0; i < 100; i++ {
+			if _, err := conn.Write([]byte{'x'}); err != nil {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+
+	select {
+	case err := <-read:
+		if err == nil {
+			t.Fatal("handler read the whole trickled body, want it cut off")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("handler still reading after 3s")
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("body read failed after %v, before the total timeout", elapsed)
+	}
+
+	// The server closes the connection rather than answering.
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			t.Fatal("connection still open after the total timeout")
+		}
+	}
+}
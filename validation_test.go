@@ -0,0 +1,44 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/banan-tech/httpserver"
+)
+
+func TestStrictRequestValidation(t *testing.T) {
+	var logs logSink
+	_, addr := startServer(t,
+		httpserver.WithLogger(logs.logger()),
+		httpserver.WithStrictRequestValidation(),
+		httpserver.WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+	)
+
+	tests := []struct {
+		name  string
+		raw   string
+		want  int
+		close bool
+	}{
+		{"plain", "GET /x HTTP/1.1\r\nHost: example.com\r\n\r\n", http.StatusOK, false},
+		{"absolute-form", "GET http://other.example/x HTTP/1.1\r\nHost: example.com\r\n\r\n", http.StatusBadRequest, true},
+		{"HTTP/1.0 without Host", "GET /x HTTP/1.0\r\n\r\n", http.StatusBadRequest, true},
+		{"HTTP/1.0 with Host", "GET /x HTTP/1.0\r\nHost: example.com\r\n\r\n", http.StatusOK, true},
+		{"duplicate Host", "GET /x HTTP/1.1\r\nHost: a.example\r\nHost: b.example\r\n\r\n", http.StatusBadRequest, true},
+		{"conflicting Content-Length", "POST /x HTTP/1.1\r\nHost: example.com\r\nContent-Length: 1\r\nContent-Length: 2\r\n\r\nab", http.StatusBadRequest, true},
+		{"OPTIONS *", "OPTIONS * HTTP/1.1\r\nHost: example.com\r\n\r\n", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := rawRequest(t, addr, tt.raw)
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if resp.Close != tt.close {
+				t.Errorf("connection close = %v, want %v", resp.Close, tt.close)
+			}
+		})
+	}
+}